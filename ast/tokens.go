@@ -7,8 +7,13 @@ const (
 	TokenBlockEnd   Token = ")"
 	TokenSugarBegin Token = "{"
 	TokenSugarEnd   Token = "}"
+	TokenListBegin  Token = "["
+	TokenListEnd    Token = "]"
+	TokenDictBegin  Token = "@[" // closed by TokenListEnd
+	TokenComment    Token = "#"
 	TokenUnwrap     Token = "$"
 	TokenTypeCast         = ":"
 	TokenStringBeg  Token = "\""
 	TokenStringEnd  Token = "\""
+	TokenFormatBeg  Token = "f\"" // f"hello {name}" - string interpolation
 )
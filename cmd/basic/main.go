@@ -6,109 +6,188 @@ import (
 	"el/parser"
 	runtime "el/runtime"
 	runtime_ext "el/runtime_ext"
+	"el/trace"
+	"flag"
 	"fmt"
+	"os"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
 )
 
 var program = `
-(let
-	# basic
-    _ (print "hello world")
-	_ (print list)
-	_ (print [1 2 3 (list 4 5 6) (lambda x {x + 3})])
-	_ (print (map (list 1 2 3) (lambda x {x + 2})))
-	_ (print [1 2 3] $[1 2 3])				# unwrap arguments
-	_ (print {1 + 2 - 3 + 4})
-
-	# fibonacci
-	fib (lambda n (match {n <= 1}
-		true n 								# if n <= 1 then n
-		(let								# else p = fib(n-1), q = fib(n-2), p + q
-			p (fib {n - 1})
-			q (fib {n - 2})
-			{p + q}		
-		)
-	))
-	_ (print "fib(20)=" (fib 20))
-
-	# deep recursion
-	count (lambda n (match {n <= 0}
-		true 0 								# if n <= 0 then 0
-		{1 + (count {n - 1})}				# else 1 + count(n-1)
-	))
-	_ (print "count(2000)=" (count 2000))
-
-	# mutual recursion
-    even (lambda n (match {n <= 0}
-		true true 							# if n <= 0 then true
-			 (odd {n - 1}) 					# else odd(n-1)
-	))
-	odd (lambda n (match {n <= 0}
-		true false 							# if n <= 0 then false
-			 (even {n - 1}) 					# else even(n-1)
-	))
-	_ (print "evens and odds:" [(odd 10) (even 10) (odd 11) (even 11) (odd 12) (even 12)])
-
-	# weird implementation
-	_ (print (let
-			f (lambda x (add x y))
-			y 2
-			(f 3)		# this works since function call uses the current frame
-	))
-
-	# nil
-	_ (print (list () nil))
-	_ (print "empty()=" ())
-
-	# simple match sanity
-	_ (print "match 1==1 ->" (match 1 1 "ok" "no"))
-	_ (print "match 1==2 ->" (match 1 2 "ok" "no"))
-
-	# arrow function 
-	_ (print "arrow function:" {x y => {x + y}})
-
-	# builtin function currying
-	f {x y => {x + y}}
-	_ (print "f 1 = " (f 1))
-	_ (print "(f 1) 2 = " ((f 1) 2))
-
-	# user-defined function currying
-	f {x y => {x + y}}
-	_ (print "f 1 = " (curry2 f 1))
-	_ (print "(f 1) 2 = " ((curry2 f 1) 2))
-
-	_ (inspect "inspect some objects ==> " 1 (lambda x y {x + y}) add)
-
-	_ (print "-----------------")
-	f {x y => {x + y}}								 # f is of type {any -> any -> unit}
-	_ (inspect "f is {data:type} ==> " f)
-	new_type (type_chain int_type int_type int_type) # make type int -> int -> int # infix operator for this has issue
-	_ (print "casting f into ==> " new_type)
-	g (type_cast new_type f)
-	_ (inspect "g is {data:type} ==> " g) 									 # g is of type {int -> int -> int}
-
-	_ (print (names)) # print all names in the current frame
-	nil
-)`
+# basic
+(print "hello world")
+(print list)
+(print [1 2 3 (list 4 5 6) (lambda x {x + 3})])
+(print (map (list 1 2 3) (lambda x {x + 2})))
+(print [1 2 3] $[1 2 3])				# unwrap arguments
+(print {1 + 2 - 3 + 4})
+
+# fibonacci
+(def fib (lambda n (match {n <= 1}
+	true n 								# if n <= 1 then n
+	(let								# else p = fib(n-1), q = fib(n-2), p + q
+		p (fib {n - 1})
+		q (fib {n - 2})
+		{p + q}
+	)
+)))
+(print "fib(20)=" (fib 20))
+
+# deep recursion
+(def count (lambda n (match {n <= 0}
+	true 0 								# if n <= 0 then 0
+	{1 + (count {n - 1})}				# else 1 + count(n-1)
+)))
+(print "count(2000)=" (count 2000))
+
+# mutual recursion, even is defined before odd
+(def even (lambda n (match {n <= 0}
+	true true 							# if n <= 0 then true
+		 (odd {n - 1}) 					# else odd(n-1)
+)))
+(def odd (lambda n (match {n <= 0}
+	true false 							# if n <= 0 then false
+		 (even {n - 1}) 				# else even(n-1)
+)))
+(print "evens and odds:" [(odd 10) (even 10) (odd 11) (even 11) (odd 12) (even 12)])
+
+# weird implementation
+(print (let
+		f (lambda x (add x y))
+		y 2
+		(f 3)		# this works since function call uses the current frame
+))
+
+# nil
+(print (list () nil))
+(print "empty()=" ())
+
+# simple match sanity
+(print "match 1==1 ->" (match 1 1 "ok" "no"))
+(print "match 1==2 ->" (match 1 2 "ok" "no"))
+
+# arrow function 
+(print "arrow function:" {x y => {x + y}})
+
+# builtin function currying
+(def f {x y => {x + y}})
+(print "f 1 = " (f 1))
+(print "(f 1) 2 = " ((f 1) 2))
+
+# user-defined function currying
+(print "f 1 = " (curry2 f 1))
+(print "(f 1) 2 = " ((curry2 f 1) 2))
+
+(inspect "inspect some objects ==> " 1 (lambda x y {x + y}) add)
+
+(print "-----------------")
+(inspect "f is {data:type} ==> " f)						# f is of type {any -> any -> unit}
+(def new_type (type_chain int_type int_type int_type))	# make type int -> int -> int # infix operator for this has issue
+(print "casting f into ==> " new_type)
+(def g (type_cast new_type f))
+(inspect "g is {data:type} ==> " g) 					# g is of type {int -> int -> int}
+
+(print (names)) # print all names in the module
+nil
+`
+
+var (
+	traceFile   = flag.String("trace", "", "write every lambda and builtin call to this file")
+	traceFormat = flag.String("trace-format", "json", "trace format: json (JSON lines) or chrome (trace-event)")
+	profileFile = flag.String("profile", "", "write a pprof profile of lambda and builtin calls to this file")
+	compile     = flag.Bool("compile", false, "compile expressions before running them instead of walking the tree")
+	check       = flag.Bool("check", false, "report every syntax error in the files given as arguments and exit")
+)
 
 func main() {
+	flag.Parse()
+	if *check {
+		os.Exit(checkFiles(flag.Args()))
+	}
 	testRuntime()
 }
+
+// checkFiles - print the syntax errors as file:line:col: message, return the exit code
+func checkFiles(files []string) int {
+	code := 0
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+			continue
+		}
+		_, diagnostics := parser.ParseAll(string(b))
+		for _, d := range diagnostics {
+			fmt.Printf("%s:%s\n", file, d)
+			code = 1
+		}
+	}
+	return code
+}
 func testRuntime() {
-	tokens := parser.Tokenize(withTemplate(program))
+	tokens := parser.Tokenize(program)
 
-	r, s := runtime_ext.NewBasicRuntime()
+	r, s := runtime_ext.NewBasicRuntime(runtime_ext.GrantIO(), runtime_ext.GrantClock(), runtime_ext.GrantRandom())
+	m, err := runtime_ext.Template(r, s)
+	if err != nil {
+		panic(err)
+	}
 
+	var tracers []trace.Tracer
+	if len(*traceFile) > 0 {
+		f, err := os.Create(*traceFile)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		switch *traceFormat {
+		case "json":
+			tracers = append(tracers, trace.NewJSONTracer(f))
+		case "chrome":
+			t := trace.NewChromeTracer(f)
+			defer t.Close()
+			tracers = append(tracers, t)
+		default:
+			panic(fmt.Sprintf("unknown trace format %s", *traceFormat))
+		}
+	}
+	if len(*profileFile) > 0 {
+		p := trace.NewProfiler()
+		defer func() {
+			f, err := os.Create(*profileFile)
+			if err != nil {
+				panic(err)
+			}
+			defer f.Close()
+			if err := p.WriteProfile(f); err != nil {
+				panic(err)
+			}
+			_ = p.WriteReport(os.Stderr)
+		}()
+		tracers = append(tracers, p)
+	}
+	if len(tracers) > 0 {
+		r.Tracer = trace.Multi(tracers...)
+	}
+
+	if *compile {
+		m.Eval = func(r runtime.Runtime, ctx context.Context, frame runtime.Frame, e ast.Expr) adt.Result[runtime.Object] {
+			return r.Compile(e)(r, ctx, frame)
+		}
+	}
+	var ps parser.State // infix declarations hold for the rest of the program
 	var e ast.Expr
 	var o runtime.Object
-	var err error
 	ctx := context.Background()
 	for len(tokens) > 0 {
-		e, tokens, err = parser.Parse(tokens)
+		e, tokens, err = ps.Parse(tokens)
 		if err != nil {
 			panic(err)
 		}
 		fmt.Println("expr\t", e)
-		if err := r.Step(ctx, s, e).Unwrap(&o); err != nil {
+		if err := m.Run(r, ctx, e).Unwrap(&o); err != nil {
 			fmt.Println("error\t", err)
 			return
 		}
@@ -116,43 +195,3 @@ func testRuntime() {
 		fmt.Println()
 	}
 }
-
-// withTemplate - add some common template to the code
-func withTemplate(s string) string {
-	return fmt.Sprintf(`
-(let
-
-# identity - identity function
-unit (lambda x x) 
-
-# get - get element from list
-get (lambda l i (unit $(slice l (range i (add i 1)))))			# get l[i]
-head (lambda l (get l 0))							# get l[0]
-rest (lambda l (slice l (range 1 (len l))))			# get l[1:]
-
-# operators
-+ add - sub x mul / div %% mod			# short hand for common operator
-== eq != ne <= le < lt > gt >= ge
-
-# map
-map (lambda l f (match (len l)
-	0 []					# if len l == 0 then return empty list
-	(let
-		first_elem (head l)
-		first_elem2 (f first_elem)
-		rest_elems (rest l)
-		rest_elems2 (map rest_elems f)	# recursive call
-		(list first_elem2 $rest_elems2)
-	)
-))
-
-# curry
-curry2  {f x => {y => (f x y)}}
-
-# type chain
--> type_chain
-
-%s
-
-)`, s)
-}
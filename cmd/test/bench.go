@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"el/ast"
+	"el/parser"
+	"el/runtime"
+	"el/runtime_ext"
+	"fmt"
+	"testing"
+)
+
+const fibProgram = `
+(let
+	fib (lambda n (match {n <= 1}
+		true n
+		{(fib {n - 1}) + (fib {n - 2})}
+	))
+	(fib 15)
+)`
+
+func runBenchmarks() {
+	for _, bm := range []struct {
+		name string
+		step stepFunc
+	}{
+		{"fib/step", treeWalk},
+		{"fib/compiled", compiled},
+	} {
+		result := testing.Benchmark(func(b *testing.B) {
+			tokens := parser.Tokenize(runtime_ext.WithTemplate(fibProgram))
+			e, _, err := parser.Parse(tokens)
+			if err != nil {
+				b.Fatal(err)
+			}
+			r, frame := runtime_ext.NewBasicRuntime(runtime_ext.GrantIO())
+			b.ResetTimer()
+			for b.Loop() {
+				benchRun(b, bm.step, r, frame, e)
+			}
+		})
+		fmt.Printf("%s\t%s\t%s\n", bm.name, result, result.MemString())
+	}
+}
+
+func benchRun(b *testing.B, step stepFunc, r runtime.Runtime, frame runtime.Frame, e ast.Expr) {
+	if err := step(r, context.Background(), frame, e).Unwrap(nil); err != nil {
+		b.Fatal(err)
+	}
+}
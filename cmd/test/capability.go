@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"el/ast"
+	"el/parser"
+	"el/runtime"
+	"el/runtime_ext"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func init() {
+	register("builtins of capabilities are only there if granted", checkCapability)
+	register("capability uses are audited", checkCapabilityAudit)
+}
+
+// evalGranted - evaluate a program in the basic runtime with the given grants
+func evalGranted(ctx context.Context, caps []runtime_ext.Capability, program string) string {
+	r, frame := runtime_ext.NewBasicRuntime(caps...)
+	tokens := parser.Tokenize(program)
+	var ps parser.State
+	var e ast.Expr
+	var o runtime.Object
+	var err error
+	for len(tokens) > 0 {
+		if e, tokens, err = ps.Parse(tokens); err != nil {
+			return fmt.Sprintf("parse error: %v", err)
+		}
+		if err := r.Step(ctx, frame, e).Unwrap(&o); err != nil {
+			return fmt.Sprintf("error: %v", err)
+		}
+	}
+	return fmt.Sprintf("output: %v", o)
+}
+
+// sandbox - a directory with a file, a subdirectory and a symlink that escapes it
+func sandbox() (string, error) {
+	dir, err := os.MkdirTemp("", "el-sandbox")
+	if err != nil {
+		return "", err
+	}
+	root := filepath.Join(dir, "root")
+	for _, err := range []error{
+		os.MkdirAll(filepath.Join(root, "sub"), 0o755),
+		os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello"), 0o644),
+		os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0o644),
+		os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(root, "link.txt")),
+	} {
+		if err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+func checkCapability() error {
+	dir, err := sandbox()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Setenv("EL_GRANTED", "yes"); err != nil {
+		return err
+	}
+	if err := os.Setenv("EL_SECRET", "no"); err != nil {
+		return err
+	}
+	granted := []runtime_ext.Capability{
+		runtime_ext.GrantFSRead(filepath.Join(dir, "root")),
+		runtime_ext.GrantEnv("EL_GRANTED", "EL_UNSET"),
+		runtime_ext.GrantClock(),
+		runtime_ext.GrantRandom(),
+	}
+	for _, c := range []struct {
+		caps    []runtime_ext.Capability
+		program string
+		want    string
+	}{
+		{nil, `(print 1)`, "error: object not found print"},
+		{nil, `(read_file "a.txt")`, "error: object not found read_file"},
+		{nil, `(capabilities)`, "output: @[]"},
+		{nil, `(add 1 2)`, "output: 3"},
+		{granted, `(read_file "a.txt")`, "output: hello"},
+		{granted, `(list_dir ".")`, "output: [a.txt link.txt sub]"},
+		{granted, `(read_file "../secret.txt")`, "error"},
+		{granted, `(read_file "link.txt")`, "error"},
+		{granted, `(read_file "/etc/passwd")`, "error"},
+		{granted, `[(getenv "EL_GRANTED") (getenv "EL_UNSET")]`, "output: [yes nil]"},
+		{granted, `(getenv "EL_SECRET")`, "error: getenv: EL_SECRET is not granted"},
+		{granted, `(let r (rand_int 3) (match (and (ge r 0) (lt r 3)) 1 "ok" r))`, "output: ok"},
+		{granted, `(gt (now) 0)`, "output: 1"},
+		{granted, `(print 1)`, "error: object not found print"},
+		{granted, `(keys (capabilities))`, "output: [clock env fs random]"},
+	} {
+		got := evalGranted(context.Background(), c.caps, c.program)
+		if c.want == "error" && strings.HasPrefix(got, "error: ") {
+			continue // paths that escape the root fail with an error of the os
+		}
+		if got != c.want {
+			return fmt.Errorf("%s: want %q got %q", c.program, c.want, got)
+		}
+	}
+
+	// sleep returns when the script is interrupted
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	want := fmt.Sprintf("error: %v", runtime.ErrorTimeout)
+	if got := evalGranted(ctx, granted, `(sleep 10000)`); got != want || time.Since(start) > time.Second {
+		return fmt.Errorf("want %q got %q after %v", want, got, time.Since(start))
+	}
+	return nil
+}
+
+func checkCapabilityAudit() error {
+	log := &runtime_ext.AuditLog{}
+	ctx, captured := runtime_ext.Capture(runtime_ext.WithAuditor(context.Background(), log), "")
+	caps := []runtime_ext.Capability{runtime_ext.GrantIO(), runtime_ext.GrantEnv()}
+	want := `error: getenv: HOME is not granted`
+	if got := evalGranted(ctx, caps, `(let _ (print "a" 1) _ (add 1 2) (getenv "HOME"))`); got != want {
+		return fmt.Errorf("want %q got %q", want, got)
+	}
+	if captured.Stdout() != "a 1\n" {
+		return fmt.Errorf("unexpected stdout %q", captured.Stdout())
+	}
+	var got []string
+	for _, event := range log.Events() {
+		s := fmt.Sprintf("%s %s %v", event.Capability, event.Builtin, event.Args)
+		if event.Err != nil {
+			s += " " + event.Err.Error()
+		}
+		got = append(got, s)
+	}
+	if want := "io print [a 1]\nenv getenv [HOME] getenv: HOME is not granted"; strings.Join(got, "\n") != want {
+		return fmt.Errorf("want %q got %q", want, got)
+	}
+	if log.Events()[0].Time.IsZero() {
+		return fmt.Errorf("events must have a time")
+	}
+	return nil
+}
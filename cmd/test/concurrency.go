@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"el/parser"
+	"el/runtime"
+	"el/runtime_ext"
+	"errors"
+	"fmt"
+	goruntime "runtime"
+	"time"
+)
+
+func init() {
+	register("spawn, chans and select", checkConcurrency)
+	register("cancelling a script stops its tasks", checkConcurrencyCancel)
+}
+
+func checkConcurrency() error {
+	for program, want := range map[string]string{
+		`(await (spawn {=> (add 1 2)}))`:                                        "output: 3",
+		`(await (spawn add 1 2))`:                                               "output: 3",
+		`(let ts (map (range 0 5) {i => (spawn {=> {i * i}})}) (map ts await))`: "output: [0 1 4 9 16]",
+		`(let fib {n => (match {n < 2} 1 n {(fib {n - 1}) + (fib {n - 2})})}
+			ts [(spawn fib 15) (spawn fib 15) (spawn fib 15)]
+			(map ts await))`: "output: [610 610 610]",
+		`(let c (chan 2)
+			_ (spawn {=> (let _ (send c 1) _ (send c 2) _ (send c 3) (close c))})
+			[(recv c) (recv c) (recv c) (recv c)])`: "output: [1 2 3 nil]",
+		`(let ping (chan) pong (chan)
+			_ (spawn {=> (send pong {(recv ping) + 1})})
+			_ (send ping 41)
+			(recv pong))`: "output: 42",
+		`(let c (chan 1) _ (send c 5) (select (recv c x {x + 1}) (default 0)))`: "output: 6",
+		`(select (recv (chan) x x) (default "none"))`:                           "output: none",
+		`(let c (chan 1) (select (send c 7 (recv c)) (default 0)))`:             "output: 7",
+		`(let c (chan) _ (close c) (select (recv c x [x])))`:                    "output: [nil]",
+		`(let c (chan) d (chan)
+			_ (spawn send d "d")
+			(select (recv c x "c") (recv d x x)))`: "output: d",
+		`(let c (chan) _ (close c) (send c 1))`:                           "error: send on closed chan",
+		`(let c (chan) _ (close c) (close c))`:                            "error: close of closed chan",
+		`(let c (chan 1) _ (close c) (select (send c 1 1)))`:              "error: send on closed chan",
+		`(await (spawn {=> (div 1 "a")}))`:                                "error: div argument must be an integer",
+		`(await 1)`:                                                       "error: await argument must be a task",
+		`(chan -1)`:                                                       "error: chan size must be a non-negative integer",
+		`(select (recv (chan) (x) 1))`:                                    "error: lvalue must be a Name: (x)",
+		`(select (default 1) (default 2))`:                                "error: select has more than 1 default clause",
+		`(select (wait 1))`:                                               "error: select clause must be (recv c x body), (send c x body) or (default body)",
+		`(let select 1 select)`:                                           "error: cannot rebind special form select",
+		`[(type_of (chan)) (type_of (spawn {=> 1}))]`:                     "output: [chan task]",
+		`(let c (chan 1) _ (send c (print "x")) (recv c))`:                "x\noutput: nil",
+		`(let c (chan 1) _ (send c (print "x")) (select (recv c x [x])))`: "x\noutput: [nil]",
+		`(let c (chan 1) (select (send c (print "y") [(recv c)])))`:       "y\noutput: [nil]",
+		`(let c (chan 1) _ (send c (print "x")) (add 1 $[(recv c)]))`:     "x\nerror: add argument must be an integer",
+	} {
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			if got := runProgram(step, program); got != want {
+				return fmt.Errorf("%s: want %q got %q", program, want, got)
+			}
+		}
+	}
+	return nil
+}
+
+// checkConcurrencyCancel - tasks blocked on chans return when the context of the script times out
+func checkConcurrencyCancel() error {
+	r, frame := runtime_ext.NewBasicRuntime(runtime_ext.GrantIO())
+	e, _, err := parser.Parse(parser.Tokenize(`(let c (chan) d (chan)
+		ts (map (range 0 10) {i => (spawn {=> (recv c)})})
+		(await (spawn {=> (select (recv c x x) (recv d x x))})))`))
+	if err != nil {
+		return err
+	}
+	before := goruntime.NumGoroutine()
+	for _, step := range []stepFunc{treeWalk, compiled} {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		err := step(r, ctx, frame, e).Err
+		cancel()
+		if !errors.Is(err, runtime.ErrorTimeout) && !errors.Is(err, runtime.ErrorInterrupt) {
+			return fmt.Errorf("want %v got %v", runtime.ErrorTimeout, err)
+		}
+	}
+	for deadline := time.Now().Add(time.Second); goruntime.NumGoroutine() > before; {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d tasks still running", goruntime.NumGoroutine()-before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return nil
+}
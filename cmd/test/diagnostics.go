@@ -0,0 +1,52 @@
+package main
+
+import (
+	"el/parser"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	register("parser reports every syntax error with its position", checkDiagnostics)
+}
+
+func checkDiagnostics() error {
+	for source, want := range map[string][]string{
+		"(add 1 2)\n[1 2]":               nil,
+		")":                              {"1:1: unexpected )"},
+		"(add 1 2))\n(sub 1 2)":          {"1:10: unexpected )"},
+		"(add 1 {2 3)\nx":                {"1:12: { at 1:8 closed by )"},
+		"(add 1\n  (mul 2 3)\n(sub 1 2)": {"1:1: unclosed ("},
+		"x (let a [1 2\ny (print \"}\" @[1 2)\nz ]\nw {}": {"2:19: @[ at 2:14 closed by )", "3:3: unexpected ]"},
+		"a f\"{x\" b\n(c":         {"1:3: unterminated format string: f\"{x\" b\n(c"},
+		"\"x # y\" (a]\n# (\n(b}": {"1:11: ( at 1:9 closed by ]", "3:3: ( at 3:1 closed by }"},
+		"f\"a}\"":                 {"1:1: unmatched } in format string: f\"a}\""},
+		"(print \"a\\":            {"1:8: unterminated string: \"a\\"},
+		"(a \"b)\n(c)":            {"1:4: unterminated string: \"b)\n(c)"},
+		"(a\n(b c]\nd)\n(e}":      {"2:5: ( at 2:1 closed by ]", "4:3: ( at 4:1 closed by }"},
+	} {
+		_, diagnostics := parser.ParseAll(source)
+		var got []string
+		for _, d := range diagnostics {
+			got = append(got, d.Error())
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			return fmt.Errorf("%q: want %q got %q", source, want, got)
+		}
+	}
+	// the valid forms around errors are still parsed
+	exprList, diagnostics := parser.ParseAll("(a b]\n(c d)\n)\n[e]")
+	if got := fmt.Sprint(exprList); len(diagnostics) != 2 || got != "[(c d) (list e)]" {
+		return fmt.Errorf("want 2 diagnostics and [(c d) (list e)] got %v and %s", diagnostics, got)
+	}
+	// a form with a continuation line in the first column is skipped as a whole
+	exprList, diagnostics = parser.ParseAll("(a\n(b c]\nd)\n(e)")
+	if got := fmt.Sprint(exprList); len(diagnostics) != 1 || got != "[(e)]" {
+		return fmt.Errorf("want 1 diagnostic and [(e)] got %v and %s", diagnostics, got)
+	}
+	// Parse over tokens without positions rejects the unterminated string too
+	if _, _, err := parser.Parse(parser.Tokenize(`(print "a\`)); err == nil || !strings.Contains(err.Error(), "unterminated string") {
+		return fmt.Errorf("want an unterminated string error got %v", err)
+	}
+	return nil
+}
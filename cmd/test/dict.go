@@ -0,0 +1,36 @@
+package main
+
+func init() {
+	register("dict literals and builtins", checkDict)
+}
+
+func checkDict() error {
+	return checkPrograms(map[string]string{
+		`(print @["b" 2 "a" 1])`:                                     "@[a 1 b 2]\noutput: <nil>",
+		`(get @["a" 1] "a")`:                                         "output: 1",
+		`(get @["a" 1] "b" 0)`:                                       "output: 0",
+		`(get @["a" 1] "b")`:                                         "error: get: b not found",
+		`(get @[[1 2] "pair"] [1 2])`:                                "output: pair",
+		`(let d @[1 "x"] e (set d 2 "y") [d e (del e 1)])`:           "output: [@[1 x] @[1 x 2 y] @[2 y]]",
+		`[(has @[1 2] 1) (has @[1 2] 2)]`:                            "output: [1 0]",
+		`(let d @["b" 2 "a" 1 3 0] [(keys d) (values d) (items d)])`: "output: [[3 a b] [0 1 2] [[3 0] [a 1] [b 2]]]",
+		`(merge @[1 1 2 2] @[2 3] @[4 4])`:                           "output: @[1 1 2 3 4 4]",
+		`(match @[1 [2]] @[1 [2]] "equal" "different")`:              "output: equal",
+		`(match [1 2] [1 2] "equal" "different")`:                    "output: equal",
+		`(type_of @[])`:                                              "output: dict",
+		`(dict print 1)`:                                             "error: dict key: {builtin: (print 1 2 (lambda x (add x 1))) - print} of type function has no order",
+		`(dict 1 2 3)`:                                               "error: dict requires an even number of arguments",
+		`(set @[] print 1)`:                                          "error: set key: {builtin: (print 1 2 (lambda x (add x 1))) - print} of type function has no order",
+		`(get @["a" 1])`:                                             "error: get requires 2 or 3 arguments",
+		`(get 1 "a")`:                                                "error: get first argument must be a dict or a list",
+		`[(get [1 2] -1) (get [1 2] 5 0)]`:                           "output: [2 0]",
+		`(get [1 2] 5)`:                                              "error: get index 5 out of range for list of length 2",
+		`(get [1 2] "a")`:                                            "error: get index must be an integer",
+		`(set [1] 3 1)`:                                              "error: set index 3 out of range for list of length 1",
+		`(del [1 2] 0)`:                                              "error: del first argument must be a dict or a set",
+		`(has 1 1)`:                                                  "error: has first argument must be a dict or a set",
+		`(keys 1)`:                                                   "error: keys first argument must be a dict",
+		`(items [1])`:                                                "error: items first argument must be a dict",
+		`(merge @[1 1] [1])`:                                         "error: merge arguments must be dicts",
+	})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"el/ast"
+	"el/parser"
+	"el/runtime"
+	"el/runtime_ext"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+func init() {
+	register("differential: tree walker and compiled code agree on examples", checkDifferential)
+}
+
+type stepFunc = func(r runtime.Runtime, ctx context.Context, frame runtime.Frame, e ast.Expr) adt.Result[runtime.Object]
+
+func treeWalk(r runtime.Runtime, ctx context.Context, frame runtime.Frame, e ast.Expr) adt.Result[runtime.Object] {
+	return r.Step(ctx, frame, e)
+}
+
+func compiled(r runtime.Runtime, ctx context.Context, frame runtime.Frame, e ast.Expr) adt.Result[runtime.Object] {
+	return r.Compile(e)(r, ctx, frame)
+}
+
+// checkPrograms - run every program of the table with the tree walker and with compiled code,
+// each must give the printed output and the final value or error of runProgram
+func checkPrograms(table map[string]string) error {
+	for program, want := range table {
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			if got := runProgram(step, program); got != want {
+				return fmt.Errorf("%s: want %q got %q", program, want, got)
+			}
+		}
+	}
+	return nil
+}
+
+// runProgram - run every top level form in the module of the template, return what was printed and the final value or error
+func runProgram(step stepFunc, program string) string {
+	return runProgramWithInput(step, program, "")
+}
+
+func runProgramWithInput(step stepFunc, program string, stdin string) string {
+	ctx, captured := runtime_ext.Capture(context.Background(), stdin)
+	result := func() string {
+		tokens := parser.Tokenize(program)
+		r, frame := runtime_ext.NewBasicRuntime(runtime_ext.GrantIO())
+		m, err := runtime_ext.Template(r, frame)
+		if err != nil {
+			return fmt.Sprintf("error: %v", err)
+		}
+		m.Eval = step
+		var ps parser.State
+		var e ast.Expr
+		var o runtime.Object
+		for len(tokens) > 0 {
+			e, tokens, err = ps.Parse(tokens)
+			if err != nil {
+				return fmt.Sprintf("parse error: %v", err)
+			}
+			if err := m.Run(r, ctx, e).Unwrap(&o); err != nil {
+				return fmt.Sprintf("error: %v", err)
+			}
+		}
+		return fmt.Sprintf("output: %v", o)
+	}()
+	return captured.Stdout() + result
+}
+
+func checkDifferential() error {
+	files, err := filepath.Glob(filepath.Join(*examplesDir, "*.el"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no examples found in %s", *examplesDir)
+	}
+	var failed []string
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		want := runProgram(treeWalk, string(b))
+		got := runProgram(compiled, string(b))
+		if want != got {
+			failed = append(failed, fmt.Sprintf("%s:\n--- step\n%s\n--- compiled\n%s", file, want, got))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s", strings.Join(failed, "\n"))
+	}
+	return nil
+}
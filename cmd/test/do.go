@@ -0,0 +1,33 @@
+package main
+
+func init() {
+	register("do evaluates in order with local def", checkDo)
+}
+
+func checkDo() error {
+	return checkPrograms(map[string]string{
+		`(do (print 1) (print 2) 3)`:           "1\n2\noutput: 3",
+		`(do)`:                                 "output: nil",
+		`(do (def x 1) (def y {x + 1}) [x y])`: "output: [1 2]",
+		`(do (def x 5))`:                       "output: 5",
+		`(do (def z 1) nil) z`:                 "error: object not found z",
+		`(def x 1) (do (def x 2) (print x)) x`: "2\noutput: 1",
+		`(do (def fact (lambda n (match {n <= 1} true 1 {n * (fact {n - 1})}))) (fact 5))`: "output: 120",
+		`(def f (lambda n (do (def m {n * 2}) (print "m" m) {m + 1}))) (f 3)`:              "m 6\noutput: 7",
+		`(let g (lambda $ xs (do (print "called") (len xs))) (g 1 2))`:                     "called\noutput: 2",
+		`(do (print "a") (def x) (print "b"))`:                                             "a\nerror: def requires 2 arguments: (def x)",
+		`(do (print "a") (undefined_name) (print "b"))`:                                    "a\nerror: object not found undefined_name",
+		`(do (let a (def b 1) a))`:                                                         "error: def is only allowed at the top level of a program or in do",
+		`(let do 1 do)`:                                                                    "error: cannot rebind special form do",
+		`(do (def x 1) (def x))`:                                                           "error: def requires 2 arguments: (def x)",
+		`(def f (lambda n (do (print n) (1 n)))) (f 2)`:                                    "2\nerror: expression cannot be executed: (1 n)",
+		`(def f (lambda n (do (print n) (f n n)))) (f 2)`:                                  "2\nerror: too many arguments to lambda",
+		`(def f (lambda n (do (sub n "a")))) (f 2)`:                                        "error: sub argument must be an integer",
+		`(do (def f (lambda a b [a b])) ((f 1) 2))`:                                        "output: [1 2]",
+		// deeper than the Go stack takes without tail calls
+		`(def loop (lambda n (do (match n 0 "done" (loop (sub n 1)))))) (loop 300000)`: "output: done",
+		`(def even (lambda n (do (let m {n - 1} (match n 0 true (odd m))))))
+		 (def odd (lambda n (do (match n 0 false (even {n - 1})))))
+		 (even 160001)`: "output: 0",
+	})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"el/ast"
+	"el/parser"
+	"el/runtime"
+	"el/runtime_ext"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	register("higher order builtins call el functions", checkHigherOrder)
+	register("higher order builtins stop when the context is cancelled", checkHigherOrderCancel)
+}
+
+func checkHigherOrder() error {
+	for program, want := range map[string]string{
+		`(map [1 2 3] {x => (mul x x)})`:                                                  "output: [1 4 9]",
+		`(map [] {x => (mul x x)})`:                                                       "output: []",
+		`(map [[1 2] [3]] len)`:                                                           "output: [2 1]",
+		`(let k 10 (map [1 2] {x => {x + k}}))`:                                           "output: [11 12]",
+		`(filter (range 0 10) {x => {x % 3 == 0}})`:                                       "output: [0 3 6 9]",
+		`(filter [1 2] {x => "yes"})`:                                                     "error: filter predicate must return a boolean, got yes",
+		`(fold_left [1 2 3] [] {acc x => (push acc x)})`:                                  "output: [1 2 3]",
+		`(fold_right [1 2 3] [] {x acc => (push acc x)})`:                                 "output: [3 2 1]",
+		`(fold_left [1 2 3 4] 0 add)`:                                                     "output: 10",
+		`[(any [1 2 3] {x => {x > 2}}) (any [] {x => true})]`:                             "output: [1 0]",
+		`[(all [1 2 3] {x => {x > 0}}) (all [1 2] {x => {x > 1}}) (all [] {x => false})]`: "output: [1 0 1]",
+		`[(find [1 2 3 4] {x => {x > 2}}) (find [1] {x => false})]`:                       "output: [3 nil]",
+		`(sort_by [3 1 2] lt)`:                                                            "output: [1 2 3]",
+		`(sort_by [[2 "a"] [1 "b"] [2 "c"] [1 "d"]] {p q => {(head p) < (head q)}})`:      "output: [[1 b] [1 d] [2 a] [2 c]]",
+		`(group_by (range 0 6) {x => {x % 3}})`:                                           "output: @[0 [0 3] 1 [1 4] 2 [2 5]]",
+		`(map [1] 3)`:                                                                     "error: cannot call 3: not a function",
+	} {
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			if got := runProgram(step, program); got != want {
+				return fmt.Errorf("%s: want %q got %q", program, want, got)
+			}
+		}
+	}
+	for program, want := range map[string]string{
+		`(group_by [1] {x => add})`: "has no order",
+		`(map [1] let)`:             "special forms cannot be called from a builtin",
+	} {
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			if got := runProgram(step, program); !strings.HasPrefix(got, "error: ") || !strings.HasSuffix(got, want) {
+				return fmt.Errorf("%s: want an error ending in %q got %q", program, want, got)
+			}
+		}
+	}
+	return nil
+}
+
+// checkHigherOrderCancel - a Go callback cancels the context, map must not call it again
+func checkHigherOrderCancel() error {
+	calls := 0
+	var cancel context.CancelFunc
+	r, frame := runtime_ext.NewBasicRuntime(runtime_ext.GrantIO())
+	frame, err := runtime_ext.Register(frame, "stop_at_2", func(x int) int {
+		calls++
+		if x == 2 {
+			cancel()
+		}
+		return x
+	})
+	if err != nil {
+		return err
+	}
+	var e ast.Expr
+	if e, _, err = parser.Parse(parser.Tokenize(`(map (range 0 10) stop_at_2)`)); err != nil {
+		return err
+	}
+	for _, step := range []stepFunc{treeWalk, compiled} {
+		calls = 0
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		err := step(r, ctx, frame, e).Err
+		cancel()
+		if !errors.Is(err, runtime.ErrorInterrupt) {
+			return fmt.Errorf("want %v got %v", runtime.ErrorInterrupt, err)
+		}
+		if calls != 3 {
+			return fmt.Errorf("want 3 calls before the interrupt got %d", calls)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"el/parser"
+	"el/runtime_ext"
+	"fmt"
+)
+
+func init() {
+	register("IO builtins use the streams of the context", checkIO)
+}
+
+func checkIO() error {
+	program := `(let
+		first (read_line)
+		second (read_line)
+		_ (eprint "read" first second)
+		rest (read_all)
+		_ (print rest)
+		(read_line)
+	)`
+	want := "c\nd\noutput: nil"
+	for _, step := range []stepFunc{treeWalk, compiled} {
+		if got := runProgramWithInput(step, program, "a\r\nb\nc\nd"); got != want {
+			return fmt.Errorf("want %q got %q", want, got)
+		}
+	}
+
+	// tasks share the stdin of the evaluation, each line goes to one of them
+	program = `(let
+		ts (map (range 0 4) {i => (spawn {=> [(read_line) (read_line)]})})
+		(sort_by (map (concat $ (map ts await)) parse_int) lt)
+	)`
+	want = "output: [10 11 12 13 14 15 16 17]"
+	for _, step := range []stepFunc{treeWalk, compiled} {
+		if got := runProgramWithInput(step, program, "10\n11\n12\n13\n14\n15\n16\n17\n"); got != want {
+			return fmt.Errorf("want %q got %q", want, got)
+		}
+	}
+
+	// separate evaluations do not mix their output
+	r, frame := runtime_ext.NewBasicRuntime(runtime_ext.GrantIO())
+	e, _, err := parser.Parse(parser.Tokenize(`(eprint "to stderr")`))
+	if err != nil {
+		return err
+	}
+	ctx1, c1 := runtime_ext.Capture(context.Background(), "")
+	_, c2 := runtime_ext.Capture(context.Background(), "")
+	if err := r.Step(ctx1, frame, e).Unwrap(nil); err != nil {
+		return err
+	}
+	if c1.Stderr() != "to stderr\n" || c1.Stdout() != "" || c2.Stderr() != "" {
+		return fmt.Errorf("unexpected captured output %q %q %q", c1.Stdout(), c1.Stderr(), c2.Stderr())
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import "fmt"
+
+func init() {
+	register("json_parse and json_stringify", checkJSON)
+}
+
+func checkJSON() error {
+	for program, want := range map[string]string{
+		`(json_parse "{\"a\": [1, 2.5, -3e2, true, null], \"b\": {\"c\": \"x\"}}")`: "output: @[a [1 2.5 -300 1 nil] b @[c x]]",
+		`(type_of (json_parse "2.5"))`:                                           "output: float",
+		`(type_of (json_parse "100000000000000000000"))`:                         "output: float",
+		`[(add (json_parse "-3e2") 1) (type_of (json_parse "2.0"))]`:             "output: [-299 int]",
+		`(match (json_parse "1e1") 10 1 0)`:                                      "output: 1",
+		`(match (json_parse "1.5") (json_parse "1.5") "same" "other")`:           "output: same",
+		`(match (json_parse "1.5") 1 "one" "other")`:                             "error: match comparison: different types",
+		`(add (json_parse "1.5") 1)`:                                             "error: add argument must be an integer",
+		`(lt (json_parse "1.5") 2)`:                                              "error: lt argument must be an integer",
+		`(get (json_parse "{\"k\": \"v\"}") "k")`:                                "output: v",
+		`(json_parse "[1, 2")`:                                                   "error: json_parse: unexpected EOF",
+		`(json_parse "1 2")`:                                                     "error: json_parse: unexpected data after the value at offset 3",
+		`(json_parse 1)`:                                                         "error: json_parse argument must be a string",
+		`(json_stringify @["b" [1 "x" nil] "a" (set_of 2 1)])`:                   `output: {"a":[1,2],"b":[1,"x",null]}`,
+		`(json_stringify (json_parse "{\"p\": 9.99, \"q\": [\"\\u00e9\\n\"]}"))`: `output: {"p":9.99,"q":["é\n"]}`,
+		`(json_stringify [1 @["a" 2]] 2)`:                                        "output: [\n  1,\n  {\n    \"a\": 2\n  }\n]",
+		`(json_stringify [1] "\t")`:                                              "output: [\n\t1\n]",
+		`(json_stringify [1] -1)`:                                                "error: json_stringify indent must not be negative",
+		`(json_stringify [1 @["a" (lambda x x)]])`:                               "error: json_stringify: cannot encode a value of type function at $[1][\"a\"]",
+		`(json_stringify @[1 2])`:                                                "error: json_stringify: key 1 of type int at $ is not a string",
+		`(json_stringify int_type)`:                                              "error: json_stringify: cannot encode a value of type type at $",
+	} {
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			if got := runProgram(step, program); got != want {
+				return fmt.Errorf("%s: want %q got %q", program, want, got)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"el/runtime"
+	"el/runtime_ext"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	register("keyword arguments", checkKeywords)
+}
+
+type priceOptions struct {
+	Discount int    `el:"discount"`
+	Currency string `el:"currency"`
+}
+
+func checkKeywords() error {
+	if err := checkPrograms(map[string]string{
+		`(let f (lambda a b (c 0) [a b c]) [(f 1 :b 2) (f :b 2 :a 1) (f 1 2 :c 3) (f :c 3 1 2)])`: "output: [[1 2 0] [1 2 0] [1 2 3] [1 2 3]]",
+		`(let f (lambda a b (c a) [a b c]) [((f :b 2) 1) ((f :c 5) 1 2) (((f :c 5) :b 2) 1)])`:    "output: [[1 2 1] [1 2 5] [1 2 5]]",
+		`(let f (lambda a $ r [a r]) (f 1 2 :a 3))`:                                               "error: argument a is given by position and by keyword",
+		`(let f (lambda a b [a b]) (f 1 :c 2))`:                                                   "error: unknown keyword argument c",
+		`(let f (lambda a $ r [a r]) (f :r [1]))`:                                                 "error: unknown keyword argument r",
+		`(let f (lambda a b [a b]) ((f :a 1) :a 2))`:                                              "error: unknown keyword argument a",
+		`(let f (lambda a b [a b]) (f :a 1 :a 2))`:                                                "error: duplicate keyword argument a",
+		`(let f (lambda a b [a b]) (f 1 :b))`:                                                     "error: keyword argument must be :name value",
+		`(let f (lambda a b [a b]) (f 1 : (b) 2))`:                                                "error: keyword must be a Name: (b)",
+		`(let x 5 f (lambda a b [a b]) (f :b (+ x 1) x))`:                                         "output: [5 6]",
+		`(add 1 :x 2)`:                                    "error: add does not take keyword arguments",
+		`(map [1] :f unit)`:                               "error: map does not take keyword arguments",
+		`(json_stringify [1] :indent 1)`:                  "output: [\n 1\n]",
+		`(json_stringify [1] :pretty 1)`:                  "error: json_stringify: unknown keyword argument pretty",
+		`(json_stringify [1] 2 :indent 1)`:                "error: json_stringify: indent is given by position and by keyword",
+		`(let f (lambda a b [a b]) (f 1 :b (sub 1 "a")))`: "error: sub argument must be an integer",
+		`(let f (lambda a b [a b]) (f :b 2 :a))`:          "error: keyword argument must be :name value",
+		`(let f (lambda a [a]) (f :1 2))`:                 "error: unknown keyword argument 1",
+		`(let f (lambda x (y 1) [x y]) ((f :y 2) 1))`:     "output: [1 2]",
+	}); err != nil {
+		return err
+	}
+
+	extend := func(frame runtime.Frame) (runtime.Frame, error) {
+		frame, err := runtime_ext.RegisterKeywords(frame, "price", func(base int, opts priceOptions) string {
+			if opts.Currency == "" {
+				opts.Currency = "EUR"
+			}
+			return fmt.Sprintf("%d %s", base*(100-opts.Discount)/100, opts.Currency)
+		})
+		if err != nil {
+			return frame, err
+		}
+		return runtime_ext.RegisterKeywords(frame, "tags", func(kw map[string]string) []string {
+			var tags []string
+			for k, v := range kw {
+				tags = append(tags, k+"="+v)
+			}
+			return tags
+		})
+	}
+	for program, want := range map[string]string{
+		`[(price 200) (price 200 :discount 10) (price :currency "USD" 200 :discount 50)]`: "[200 EUR 180 EUR 100 USD]",
+		`(tags :a "x")`:             "[a=x]",
+		`(price 200 :rebate 10)`:    "price: unknown keyword argument rebate",
+		`(price 200 :discount "a")`: "price keyword arguments: field discount: cannot convert a of type string into int",
+		`(price)`:                   "price requires 1 arguments",
+	} {
+		got, err := evalWith(extend, program)
+		if err != nil {
+			got = err.Error()
+		}
+		if !strings.HasPrefix(got, want) {
+			return fmt.Errorf("%s: want %q got %q", program, want, got)
+		}
+	}
+	if _, err := runtime_ext.RegisterKeywords(runtime.Frame{}, "f", func(xs ...int) int { return 0 }); err == nil {
+		return fmt.Errorf("a variadic keyword parameter should be rejected")
+	}
+	return nil
+}
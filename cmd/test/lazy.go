@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"el/parser"
+	"el/runtime"
+	"el/runtime_ext"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func init() {
+	register("lazy sequences", checkLazy)
+	register("unbounded sequences can be interrupted", checkLazyInterrupt)
+}
+
+func checkLazy() error {
+	for program, want := range map[string]string{
+		`(range 0 3)`:                                                        "output: seq{range 0 3}",
+		`(to_list (range 0 3))`:                                              "output: [0 1 2]",
+		`(to_list (take (range 0 1000000000) 3))`:                            "output: [0 1 2]",
+		`(to_list (take (drop (range 0 100000) 99997) 3))`:                   "output: [99997 99998 99999]",
+		`(to_list (take (iterate {x => (mul x 2)} 1) 4))`:                    "output: [1 2 4 8]",
+		`[(to_list (repeat "a" 2)) (to_list (take (repeat 0) 2))]`:           "output: [[a a] [0 0]]",
+		`(to_list (take_while (iterate {x => (add x 1)} 0) {x => {x < 3}}))`: "output: [0 1 2]",
+		`(to_list (take (filter_lazy (map_lazy (iterate {x => (add x 1)} 0) {x => (mul x x)}) {x => {x % 2 == 1}}) 3))`: "output: [1 9 25]",
+		`(to_list (zip [1 2 3] (iterate {x => (add x 1)} 10) (repeat "z")))`:                                            "output: [[1 10 z] [2 11 z] [3 12 z]]",
+		`(let s (map_lazy [1 2] {x => (add x 1)}) [(to_list s) (to_list s)])`:                                           "output: [[2 3] [2 3]]",
+		`(fold_left (range 0 100001) 0 add)`:                                                                            "output: 5000050000",
+		`[(len (range 2 5)) (get (to_list (range 2 5)) -1) (map (range 0 3) {x => (add x 1)})]`:                         "output: [3 4 [1 2 3]]",
+		`(find (iterate {x => (add x 1)} 0) {x => {(mul x x) > 50}})`:                                                   "output: 8",
+		`(to_list (map_lazy [1 "a"] {x => (add x 1)}))`:                                                                 "error: add argument must be an integer",
+		`[$(range 0 3) $(map_lazy [1 2] {x => (mul x 10)})]`:                                                            "output: [0 1 2 10 20]",
+		`(add $(range 1 3))`: "output: 3",
+		`(to_list 1)`:        "error: to_list argument must be a set, a seq or a list",
+		`(to_list (take (filter_lazy (iterate {x => (add x 1)} 0) {x => {x < 3}}) 3))`:                                  "output: [0 1 2]",
+		`(to_list (take (filter_lazy (range 0 1000000000) {x => {x < 3}}) 3))`:                                          "output: [0 1 2]",
+		`(to_list (take (map_lazy (range 0 10) {x => (do (print x) x)}) 2))`:                                            "0\n1\noutput: [0 1]",
+		`[(to_list (take (map_lazy [1 "a"] {x => (add x 1)}) 1)) (to_list (take (map_lazy ["a"] {x => (add x 1)}) 0))]`: "output: [[2] []]",
+	} {
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			if got := runProgram(step, program); got != want {
+				return fmt.Errorf("%s: want %q got %q", program, want, got)
+			}
+		}
+	}
+	return nil
+}
+
+// checkLazyInterrupt - materializing an unbounded seq must stop at the deadline
+func checkLazyInterrupt() error {
+	r, frame := runtime_ext.NewBasicRuntime(runtime_ext.GrantIO())
+	for _, program := range []string{`(len (repeat 1))`, `(add $(repeat 1))`} {
+		e, _, err := parser.Parse(parser.Tokenize(program))
+		if err != nil {
+			return err
+		}
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			err := step(r, ctx, frame, e).Err
+			cancel()
+			if !errors.Is(err, runtime.ErrorTimeout) && !errors.Is(err, runtime.ErrorInterrupt) {
+				return fmt.Errorf("%s: want an interrupt got %v", program, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"el/parser"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	register("lexer keeps brackets and comments inside strings", checkLexer)
+}
+
+func checkLexer() error {
+	for source, want := range map[string][]string{
+		`"issue #42"`:                      {`"issue #42"`},
+		`"[a]" "@[b]"`:                     {`"[a]"`, `"@[b]"`},
+		`[1 [2]] # a [comment] "x"`:        {`[`, `1`, `[`, `2`, `]`, `]`},
+		`@[k [v]]`:                         {`@[`, `k`, `[`, `v`, `]`, `]`},
+		"a # one\nb#two\n\"#\"":            {`a`, `b`, `"#"`},
+		`"a\"#b" "c\\" d`:                  {`"a\"#b"`, `"c\\"`, `d`},
+		`"x\\\"]#"`:                        {`"x\\\"]#"`},
+		`(print "https://x.io/a#b?q=[1]")`: {`(`, `print`, `"https://x.io/a#b?q=[1]"`, `)`},
+		`f"{[1 2]} #{n}" [3]`:              {`f"{[1 2]} #{n}"`, `[`, `3`, `]`},
+		`f"{(get d "}")}"`:                 {`f"{(get d "}")}"`},
+		`{x => [x]}`:                       {`{`, `x`, `=>`, `[`, `x`, `]`, `}`},
+		"\"# not a comment\" # comment":    {`"# not a comment"`},
+	} {
+		if got := parser.Tokenize(source); strings.Join(got, "\x00") != strings.Join(want, "\x00") {
+			return fmt.Errorf("%s: want %q got %q", source, want, got)
+		}
+	}
+	for program, want := range map[string]string{
+		`"issue #42"`:                     "output: issue #42",
+		`["[a]" "@[b]" "]"]`:              "output: [[a] @[b] ]]",
+		`(len "[x] #1")`:                  "output: 6",
+		`(print "- [ ] task # todo") 1`:   "- [ ] task # todo\noutput: 1",
+		`@["#" [1]]   # trailing comment`: "output: @[# [1]]",
+		`(let n 42 f"issue #{n} [open]")`: "output: issue #42 [open]",
+		`(split "a]b" "]")`:               "output: [a b]",
+		`"tab\there \"q\" \\ #"`:          "output: tab\there \"q\" \\ #",
+	} {
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			if got := runProgram(step, program); got != want {
+				return fmt.Errorf("%s: want %q got %q", program, want, got)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+func init() {
+	register("list builtins", checkList)
+}
+
+func checkList() error {
+	return checkPrograms(map[string]string{
+		`[(get [1 2 3] 0) (get [1 2 3] -1) (get [1 2 3] 5 "none")]`: "output: [1 3 none]",
+		`(get [1 2 3] 3)`:        "error: get index 3 out of range for list of length 3",
+		`(get [1 2 3] -4)`:       "error: get index -4 out of range for list of length 3",
+		`(slice [1 2 3] [2 -3])`: "output: [3 1]",
+		`(slice [1 2 3] [3])`:    "error: slice index 3 out of range for list of length 3",
+		`(set [1 2 3] -1 9)`:     "output: [1 2 9]",
+		`(set [] 0 9)`:           "error: set index 0 out of range for list of length 0",
+		`[(insert [1 2] 0 0) (insert [1 2] 2 3) (insert [1 2] -1 3)]`: "output: [[0 1 2] [1 2 3] [1 2 3]]",
+		`(insert [1 2] 3 0)`:          "error: insert index 3 out of range for list of length 2",
+		`(delete [1 2 3] 1)`:          "output: [1 3]",
+		`(concat [1 2] [] [3] [[4]])`: "output: [1 2 3 [4]]",
+		`[(split_at [1 2 3] 1) (split_at [1 2 3] 3) (split_at [1 2 3] 0)]`: "output: [[[1] [2 3]] [[1 2 3] []] [[] [1 2 3]]]",
+		`(split_at [1 2 3] -1)`:                  "error: split_at index must be a non-negative integer",
+		`(split_at [1 2 3] 4)`:                   "error: split_at index 4 out of range for list of length 3",
+		`(reverse [1 2 3])`:                      "output: [3 2 1]",
+		`(let l [1 2] [(push l 3 4) (pop l) l])`: "output: [[1 2 3 4] [1] [1 2]]",
+		`(pop [])`:                               "error: pop from empty list",
+		`[(take [1 2 3] 2) (take [1 2 3] 5) (drop [1 2 3] 1) (drop [1 2 3] 5)]`: "output: [[1 2] [1 2 3] [2 3] []]",
+		`(take [1 2 3] -1)`:               "error: take count must be a non-negative integer",
+		`(drop (range 0 3) -1)`:           "error: drop count must be a non-negative integer",
+		`[(head [1 2 3]) (rest [1 2 3])]`: "output: [1 [2 3]]",
+		`(delete [1] 3)`:                  "error: delete index 3 out of range for list of length 1",
+		`(delete [1 2] "a")`:              "error: delete index must be an integer",
+		`(insert [1 2] "a" 0)`:            "error: insert index must be an integer",
+		`(slice [1] ["a"])`:               "error: slice second argument must be a list of integers",
+		`(take [1] "a")`:                  "error: take count must be a non-negative integer",
+		`(delete 1 0)`:                    "error: delete argument must be a list",
+		`(reverse 1)`:                     "error: reverse argument must be a list",
+		`(push 1 2)`:                      "error: push argument must be a list",
+		`(concat [1] 2)`:                  "error: concat argument must be a list",
+		`(take 1 2)`:                      "error: take argument must be a list",
+		`(len 1)`:                         "error: len argument must be a list, seq, dict, set or string",
+		`(head [])`:                       "error: get index 0 out of range for list of length 0",
+	})
+}
@@ -1 +1,44 @@
 package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// check - a named self check, run by `go run ./cmd/test`
+type check struct {
+	name string
+	run  func() error
+}
+
+var checks []check
+
+func register(name string, run func() error) {
+	checks = append(checks, check{name: name, run: run})
+}
+
+var (
+	examplesDir = flag.String("examples", "examples", "directory of .el example programs")
+	bench       = flag.Bool("bench", false, "run benchmarks after the checks")
+)
+
+func main() {
+	flag.Parse()
+	failed := 0
+	for _, c := range checks {
+		if err := c.run(); err != nil {
+			failed++
+			fmt.Printf("FAIL\t%s\n\t%v\n", c.name, err)
+			continue
+		}
+		fmt.Printf("ok\t%s\n", c.name)
+	}
+	if *bench {
+		runBenchmarks()
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d checks failed\n", failed, len(checks))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"el/runtime_ext"
+	"fmt"
+	"reflect"
+)
+
+func init() {
+	register("marshal Go values to and from el objects", checkMarshal)
+}
+
+type marshalItem struct {
+	Name  string `el:"name"`
+	Count int    `el:"count,omitempty"`
+	Tags  []string
+	Skip  string `el:"-"`
+}
+
+type marshalTree struct {
+	Value    int
+	Children []*marshalTree
+	Next     *marshalTree
+}
+
+func checkMarshal() error {
+	for _, c := range []struct {
+		in   any
+		repr string
+	}{
+		{42, "42"},
+		{true, "1"},
+		{"hi", "hi"},
+		{[]int{1, 2, 3}, "[1 2 3]"},
+		{map[string]int{"b": 2, "a": 1}, "@[a 1 b 2]"},
+		{map[int][]string{2: {"x"}, 1: nil}, "@[1 [] 2 [x]]"},
+		{marshalItem{Name: "n", Tags: []string{"t"}, Skip: "s"}, "@[Tags [t] name n]"},
+		{&marshalItem{Name: "n", Count: 3}, "@[Tags [] count 3 name n]"},
+		{&marshalTree{Value: 1, Next: &marshalTree{Value: 2}}, "@[Children [] Next @[Children [] Next nil Value 2] Value 1]"},
+	} {
+		o, err := runtime_ext.ToObject(c.in)
+		if err != nil {
+			return fmt.Errorf("ToObject(%#v): %w", c.in, err)
+		}
+		if o.String() != c.repr {
+			return fmt.Errorf("ToObject(%#v): want %s got %s", c.in, c.repr, o)
+		}
+		// round trip
+		out := reflect.New(reflect.TypeOf(c.in))
+		if err := runtime_ext.FromObject(o, out.Interface()); err != nil {
+			return fmt.Errorf("FromObject(%s): %w", o, err)
+		}
+		back, err := runtime_ext.ToObject(out.Elem().Interface())
+		if err != nil {
+			return err
+		}
+		if back.String() != c.repr {
+			return fmt.Errorf("round trip of %#v: want %s got %s", c.in, c.repr, back)
+		}
+	}
+
+	var anyValue any
+	o, _ := runtime_ext.ToObject(map[string]any{"a": []any{1, "x"}})
+	if err := runtime_ext.FromObject(o, &anyValue); err != nil {
+		return err
+	}
+	if want := map[string]any{"a": []any{1, "x"}}; !reflect.DeepEqual(anyValue, want) {
+		return fmt.Errorf("FromObject into any: want %v got %v", want, anyValue)
+	}
+
+	var n int
+	if err := runtime_ext.FromObject(o, &n); err == nil {
+		return fmt.Errorf("FromObject of a dict into an int should fail")
+	}
+	if o, err := runtime_ext.ToObject(1.5); err != nil || o.String() != "1.5" {
+		return fmt.Errorf("ToObject of a float: %v %v", o, err)
+	}
+	if _, err := runtime_ext.ToObject(1i); err == nil {
+		return fmt.Errorf("ToObject of a complex number should fail")
+	}
+	return nil
+}
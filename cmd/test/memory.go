@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"el/parser"
+	"el/runtime"
+	"el/runtime_ext"
+	"errors"
+	"fmt"
+)
+
+func init() {
+	register("evaluations stop at Runtime.MemoryLimit", checkMemory)
+	register("allocations are charged once", checkMemoryCharge)
+}
+
+func checkMemory() error {
+	r, frame := runtime_ext.NewBasicRuntime()
+	r.MemoryLimit = 1 << 20
+	eval := func(ctx context.Context, step stepFunc, program string) (runtime.Object, error) {
+		e, _, err := parser.Parse(parser.Tokenize(program))
+		if err != nil {
+			return nil, err
+		}
+		var o runtime.Object
+		err = step(r, ctx, frame, e).Unwrap(&o)
+		return o, err
+	}
+	for _, program := range []string{
+		`(to_list (range 0 100000000))`,
+		`(len (iterate {l => (concat l l)} [1]))`,
+		`(let double (lambda l n (match n 0 l (double (concat l l) (sub n 1)))) (double [1] 40))`,
+		`(let double (lambda s n (match n 0 s (double (concat s s) (sub n 1)))) (double "ab" 40))`,
+		`(map (range 0 100000) {x => {y => y}})`,
+		`(await (spawn {=> (to_list (repeat 1))}))`,
+		`(pmap (range 0 4) {x => (to_list (range 0 100000000))})`,
+		`(let big (to_list (range 0 2000)) (fold_left (range 0 400) 0 {acc x => (len (reverse big))}))`,
+		`(let big (to_list (range 0 2000)) (fold_left (range 0 400) 0 {acc x => (len (map big {y => y}))}))`,
+		`(let big (to_list (range 0 2000)) (fold_left (range 0 400) 0 {acc x => (len (sort_by big lt))}))`,
+	} {
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			if _, err := eval(context.Background(), step, program); !errors.Is(err, runtime.ErrorOutOfMemory) {
+				return fmt.Errorf("%s: want %v got %v", program, runtime.ErrorOutOfMemory, err)
+			}
+		}
+	}
+
+	for _, step := range []stepFunc{treeWalk, compiled} {
+		o, err := eval(context.Background(), step, `(len (to_list (range 0 1000)))`)
+		if err != nil || o.String() != "1000" {
+			return fmt.Errorf("want 1000 got %v %v", o, err)
+		}
+		// a budget in the context is shared by the evaluations under it
+		ctx := runtime.WithMemoryLimit(context.Background(), 10000)
+		if _, err := eval(ctx, step, `(to_list (range 0 400))`); err != nil {
+			return err
+		}
+		if used := runtime.MemoryUsed(ctx); used < 400*16 || used > 10000 {
+			return fmt.Errorf("unexpected memory used %d", used)
+		}
+		if _, err := eval(ctx, step, `(to_list (range 0 400))`); !errors.Is(err, runtime.ErrorOutOfMemory) {
+			return fmt.Errorf("want %v got %v", runtime.ErrorOutOfMemory, err)
+		}
+	}
+	return nil
+}
+
+// checkMemoryCharge - a new list of n elements is charged 32 + 16n bytes, once, what it shares is not charged again
+func checkMemoryCharge() error {
+	r, frame := runtime_ext.NewBasicRuntime()
+	for program, want := range map[string]int64{
+		`(len (to_list (range 0 1000)))`:              32 + 16*1000,
+		`(to_list (range 0 1000))`:                    32 + 16*1000,
+		`(len (range 0 1000))`:                        32 + 16*1000,
+		`(map (range 0 2) {x => (len (range 0 10))})`: (64 + 16) + 2*(32+16*10) + 32 + 16*2,
+		`(concat [1 2] [3 4 5])`:                      (32 + 16*2) + (32 + 16*3) + 32 + 16*5,
+		`(reverse [1 2 3])`:                           2 * (32 + 16*3),
+		`(push [1] 2 3)`:                              (32 + 16) + 32 + 16*2,
+		`(filter [1 2 3] {x => (gt x 1)})`:            (32 + 16*3) + (64 + 16) + 32 + 16*2,
+		`(concat "ab" "cde")`:                         16 + 5,
+	} {
+		e, _, err := parser.Parse(parser.Tokenize(program))
+		if err != nil {
+			return err
+		}
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			ctx := runtime.WithMemoryLimit(context.Background(), 1<<20)
+			if err := step(r, ctx, frame, e).Unwrap(nil); err != nil {
+				return err
+			}
+			if got := runtime.MemoryUsed(ctx); got != want {
+				return fmt.Errorf("%s: want %d bytes charged got %d", program, want, got)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+func init() {
+	register("top level def forms share a module frame", checkModule)
+}
+
+func checkModule() error {
+	return checkPrograms(map[string]string{
+		`(def x 2) (def y {x + 1}) (print x y) [x y]`:                                 "2 3\noutput: [2 3]",
+		`(def fact (lambda n (match {n <= 1} true 1 {n * (fact {n - 1})}))) (fact 5)`: "output: 120",
+		`(def even (lambda n (match n 0 true (odd {n - 1}))))
+		 (def odd (lambda n (match n 0 false (even {n - 1}))))
+		 [(even 10) (odd 7)]`: "output: [1 1]",
+		`(define x 1) (def get_x (lambda _ x)) (def x 2) [(get_x 0) x]`: "output: [1 2]",
+		`(def f (lambda x x)) f`:                         "output: {closure{...}; x => x}",
+		`(def x (concat "on" "ce")) (print x) (def y x)`: "once\noutput: once",
+		`(def three {1 + 2})`:                            "output: 3",
+		`(let a (def b 1) a)`:                            "error: def is only allowed at the top level of a program or in do",
+		`(def f (lambda (def x 1))) (f)`:                 "error: def is only allowed at the top level of a program or in do",
+		`(def x)`:                                        "error: def requires 2 arguments: (def x)",
+		`(def x 1 2)`:                                    "error: def requires 2 arguments: (def x 1 2)",
+		`(define x)`:                                     "error: define requires 2 arguments: (define x)",
+		`(def x (sub 1 "a")) x`:                          "error: sub argument must be an integer",
+		`(def x 1) (def y (x 2))`:                        "error: expression cannot be executed: (x 2)",
+		`(def f (lambda n n)) (f 1 2)`:                   "error: too many arguments to lambda",
+		`(def (x) 1)`:                                    "error: lvalue must be a Name: (x)",
+		`(def match 1)`:                                  "error: cannot rebind special form match",
+		`(let def 1 def)`:                                "error: cannot rebind special form def",
+		`(def x 1) (print "a") (undefined_name) (print "b")`: "a\nerror: object not found undefined_name",
+	})
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"el/parser"
+	"el/runtime"
+	"el/runtime_ext"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+func init() {
+	register("pmap and plet evaluate in parallel", checkParallel)
+	register("pmap runs on at most Runtime.Workers goroutines", checkParallelWorkers)
+	register("the first failure of pmap and plet cancels the calls after it", checkParallelCancel)
+	register("nested pmap shares the workers of the evaluation", checkParallelNested)
+	register("pmap and plet return the error of the lowest index", checkParallelFirstError)
+}
+
+func checkParallel() error {
+	for program, want := range map[string]string{
+		`(pmap (range 0 10) {x => {x * x}})`:              "output: [0 1 4 9 16 25 36 49 64 81]",
+		`(pmap [] {x => x})`:                              "output: []",
+		`(plet a (add 1 2) b (mul 3 4) [a b])`:            "output: [3 12]",
+		`(plet f {x => {x + 1}} (f 1))`:                   "output: 2",
+		`(plet 1)`:                                        "output: 1",
+		`(let a 1 (plet a 2 b a [a b]))`:                  "output: [2 1]",
+		`(pmap [1 2 3] {x => (match x 2 (div 1 "a") x)})`: "error: div argument must be an integer",
+		`(plet a 1 b 2)`:                                  "error: plet requires at least 1 arguments and odd number of arguments",
+		`(plet plet 1 2)`:                                 "error: cannot rebind special form plet",
+		`(pmap 1 {x => x})`:                               "error: pmap argument must be a list",
+	} {
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			if got := runProgram(step, program); got != want {
+				return fmt.Errorf("%s: want %q got %q", program, want, got)
+			}
+		}
+	}
+	return nil
+}
+
+// checkParallelWorkers - count the calls of a Go function that are running at the same time
+func checkParallelWorkers() error {
+	for _, workers := range []int{1, 3} {
+		var running, peak atomic.Int64
+		r, frame := runtime_ext.NewBasicRuntime(runtime_ext.GrantIO())
+		r.Workers = workers
+		frame, err := runtime_ext.Register(frame, "slow", func(x int) int {
+			n := running.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			return x
+		})
+		if err != nil {
+			return err
+		}
+		e, _, err := parser.Parse(parser.Tokenize(`(pmap (range 0 12) slow)`))
+		if err != nil {
+			return err
+		}
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			peak.Store(0)
+			var o runtime.Object
+			if err := step(r, context.Background(), frame, e).Unwrap(&o); err != nil {
+				return err
+			}
+			if got := o.String(); got != "[0 1 2 3 4 5 6 7 8 9 10 11]" {
+				return fmt.Errorf("workers %d: got %s", workers, got)
+			}
+			if got := peak.Load(); got != int64(workers) {
+				return fmt.Errorf("workers %d: %d calls ran at the same time", workers, got)
+			}
+		}
+	}
+	return nil
+}
+
+// checkParallelCancel - the calls after the failing one block until they are cancelled
+func checkParallelCancel() error {
+	r, frame := runtime_ext.NewBasicRuntime(runtime_ext.GrantIO())
+	r.Workers = 4
+	for _, program := range []string{
+		`(plet a (div 1 "a") b (recv (chan)) a)`,
+		`(pmap [0 1 2 3] {x => (match x 0 (div 1 "a") (recv (chan)))})`,
+	} {
+		e, _, err := parser.Parse(parser.Tokenize(program))
+		if err != nil {
+			return err
+		}
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			want := "div argument must be an integer"
+			if err := step(r, context.Background(), frame, e).Err; err == nil || err.Error() != want {
+				return fmt.Errorf("%s: want %s got %v", program, want, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkParallelNested - count the calls of a Go function inside nested pmap that are running at the same time
+func checkParallelNested() error {
+	for _, workers := range []int{1, 2, 3} {
+		var running, peak atomic.Int64
+		r, frame := runtime_ext.NewBasicRuntime(runtime_ext.GrantIO())
+		r.Workers = workers
+		frame, err := runtime_ext.Register(frame, "slow", func(x int) int {
+			n := running.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(2 * time.Millisecond)
+			running.Add(-1)
+			return x
+		})
+		if err != nil {
+			return err
+		}
+		program := `(pmap (range 0 4) {x => (len (pmap (range 0 4) {y => (slow y)}))})`
+		e, _, err := parser.Parse(parser.Tokenize(program))
+		if err != nil {
+			return err
+		}
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			peak.Store(0)
+			var o runtime.Object
+			if err := step(r, context.Background(), frame, e).Unwrap(&o); err != nil {
+				return err
+			}
+			if got := o.String(); got != "[4 4 4 4]" {
+				return fmt.Errorf("workers %d: got %s", workers, got)
+			}
+			if got := peak.Load(); got > int64(workers) {
+				return fmt.Errorf("workers %d: %d calls ran at the same time", workers, got)
+			}
+		}
+	}
+	return nil
+}
+
+// checkParallelFirstError - the later call fails first, its error is not the one returned
+func checkParallelFirstError() error {
+	r, frame := runtime_ext.NewBasicRuntime(runtime_ext.GrantIO())
+	r.Workers = 4
+	frame, err := runtime_ext.Register(frame, "fail_after", func(ms int, msg string) (int, error) {
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+		return 0, errors.New(msg)
+	})
+	if err != nil {
+		return err
+	}
+	for _, program := range []string{
+		`(pmap [[20 "first"] [0 "second"] [0 "third"]] {x => (fail_after $x)})`,
+		`(plet a (fail_after 20 "first") b (fail_after 0 "second") [a b])`,
+	} {
+		e, _, err := parser.Parse(parser.Tokenize(program))
+		if err != nil {
+			return err
+		}
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			if err := step(r, context.Background(), frame, e).Err; err == nil || !strings.Contains(err.Error(), "first") {
+				return fmt.Errorf("%s: want the error first got %v", program, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+func init() {
+	register("optional and rest parameters", checkParams)
+}
+
+func checkParams() error {
+	return checkPrograms(map[string]string{
+		`(let f (lambda x $ rest [x rest]) [(f 1) (f 1 2 3) (f 1 $[2 3])])`:      "output: [[1 []] [1 [2 3]] [1 [2 3]]]",
+		`(let sum (lambda $ xs (fold_left xs 0 add)) [(sum) (sum 1 2 3)])`:       "output: [0 6]",
+		`(let f (lambda x (y 10) (+ x y)) [(f 1) (f 1 2)])`:                      "output: [11 3]",
+		`(let f (lambda x (y x) (z (+ x y)) [x y z]) [(f 1) (f 1 2) (f 1 2 5)])`: "output: [[1 1 2] [1 2 3] [1 2 5]]",
+		`(let f (lambda a b (c 0) $ more [a b c more]) [(f 1 2) (f 1 2 3 4 5)])`: "output: [[1 2 0 []] [1 2 3 [4 5]]]",
+		`(let f (lambda a b (c 0) $ more [a b c more]) ((f 1) 2 3 4))`:           "output: [1 2 3 [4]]",
+		`(let y 7 f (lambda x (y 10) (+ x y)) (f 1))`:                            "output: 11",
+		`(let f (lambda x (y 10) (+ x y)) (f 1 2 3))`:                            "error: too many arguments to lambda",
+		`(let f (lambda x (y (sub 1 "a")) y) (f 1 2))`:                           "output: 2",
+		`(let f (lambda x (y (sub x "a")) y) (f 1))`:                             "error: sub argument must be an integer",
+		`(lambda $ a b a)`:                                       "error: rest parameter must be the last parameter: $",
+		`(lambda (y 1) x x)`:                                     "error: parameter x without default follows optional parameters",
+		`(lambda (y 1 2) y)`:                                     "error: optional parameter must be (name default): (y 1 2)",
+		`(lambda $ (x) x)`:                                       "error: lvalue must be a Name: (x)",
+		`(lambda x (y 1) $ z x)`:                                 "output: {closure{...}; x (y 1) $ z => x}",
+		`(type_of (lambda x (y 1) $ z x))`:                       "output: {any -> unit}",
+		`(let f {$ xs => (len xs)} (f 1 2 3))`:                   "output: 3",
+		`(lambda $ let x)`:                                       "error: cannot rebind special form let",
+		`(let f (lambda x $ rest [x rest]) (f 1 $ (range 0 2)))`: "output: [1 [0 1]]",
+		`(let f (lambda x $ rest [x rest]) (f 1 $ 2))`:           "error: unwrapping argument must be a list, a seq or an unwrap",
+		`(let f (lambda x $ rest [x rest]) (f 1 $))`:             "error: unwrapping argument empty",
+	})
+}
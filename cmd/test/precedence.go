@@ -0,0 +1,63 @@
+package main
+
+import (
+	"el/parser"
+	"fmt"
+)
+
+func init() {
+	register("sugar blocks group operators by precedence", checkPrecedence)
+}
+
+func checkPrecedence() error {
+	for source, want := range map[string]string{
+		"{1 + 2 * 3}":                  "(+ 1 (* 2 3))",
+		"{1 * 2 + 3}":                  "(+ (* 1 2) 3)",
+		"{a - b - c}":                  "(- (- a b) c)",
+		"{a -> b -> c}":                "(-> a (-> b c))",
+		"{a + b == c && d < e || f}":   "(|| (&& (== (+ a b) c) (< d e)) f)",
+		"{- a * b}":                    "(* (neg a) b)",
+		"{not a == b}":                 "(not (== a b))",
+		"{! a && b}":                   "(&& (not a) b)",
+		"{a * b <+> c}":                "(* a (<+> b c))",
+		"(infixl 1 <+>) {a * b <+> c}": "() (<+> (* a b) c)",
+		"(infixr 6 ++) {a ++ b ++ c}":  "() (++ a (++ b c))",
+		"{a b => {a + b}}":             "(lambda a b (+ a b))",
+		"{a : int}":                    "(type.cast int a)",
+	} {
+		exprList, diagnostics := parser.ParseAll(source)
+		if len(diagnostics) > 0 {
+			return fmt.Errorf("%s: %v", source, diagnostics)
+		}
+		got := ""
+		for i, expr := range exprList {
+			if i > 0 {
+				got += " "
+			}
+			got += expr.String()
+		}
+		if got != want {
+			return fmt.Errorf("%s: want %s got %s", source, want, got)
+		}
+	}
+	for program, want := range map[string]string{
+		`{1 + 2 x 3}`:                                     "output: 7",
+		`{10 - 4 - 3}`:                                    "output: 3",
+		`{2 * 3 % 4}`:                                     "output: 2",
+		`{1 + 1 == 2 && 3 > 4 || 1}`:                      "output: 1",
+		`[{- 2 + 5} {! 0} {not 1 == 2}]`:                  "output: [3 1 1]",
+		`(let n 4 f"{n * n - 1}")`:                        "output: 15",
+		`(let _ (infixr 5 <+>) <+> sub {10 <+> 4 <+> 3})`: "output: 9",
+		`(infixr 5 <+>) (def <+> sub) {10 <+> 4 <+> 3}`:   "output: 9",
+		`(def <+> sub) {10 <+> 4 <+> 3}`:                  "output: 3",
+		`{1 +}`:                                           "parse error: missing operand after +",
+		`(infixl x <+>)`:                                  "parse error: infixl precedence must be an integer: x",
+	} {
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			if got := runProgram(step, program); got != want {
+				return fmt.Errorf("%s: want %q got %q", program, want, got)
+			}
+		}
+	}
+	return nil
+}
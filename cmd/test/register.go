@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"el/ast"
+	"el/parser"
+	"el/runtime"
+	"el/runtime_ext"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	register("register Go functions by reflection", checkRegister)
+}
+
+// evalWith - evaluate a program in the basic runtime after extending its frame
+func evalWith(extend func(frame runtime.Frame) (runtime.Frame, error), program string) (string, error) {
+	r, frame := runtime_ext.NewBasicRuntime(runtime_ext.GrantIO())
+	frame, err := extend(frame)
+	if err != nil {
+		return "", err
+	}
+	tokens := parser.Tokenize(program)
+	var ps parser.State
+	var e ast.Expr
+	var o runtime.Object
+	for len(tokens) > 0 {
+		if e, tokens, err = ps.Parse(tokens); err != nil {
+			return "", err
+		}
+		if err := r.Step(context.Background(), frame, e).Unwrap(&o); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprint(o), nil
+}
+
+func checkRegister() error {
+	extend := func(frame runtime.Frame) (runtime.Frame, error) {
+		for name, f := range map[runtime.Name]any{
+			"sum": func(xs ...int) int {
+				n := 0
+				for _, x := range xs {
+					n += x
+				}
+				return n
+			},
+			"repeat": func(ctx context.Context, s string, n uint8) string { return strings.Repeat(s, int(n)) },
+			"words":  func(s string) []string { return strings.Fields(s) },
+			"count": func(m map[string]int) (int, error) {
+				if len(m) == 0 {
+					return 0, errors.New("empty")
+				}
+				return len(m), nil
+			},
+			"invert": func(b bool) bool { return !b },
+			"index": func(m map[int]string) map[string]int {
+				out := map[string]int{}
+				for k, v := range m {
+					out[v] = k
+				}
+				return out
+			},
+		} {
+			var err error
+			if frame, err = runtime_ext.Register(frame, name, f); err != nil {
+				return frame, err
+			}
+		}
+		return frame, nil
+	}
+	for program, want := range map[string]string{
+		`(sum 1 2 3)`:               "6",
+		`(sum)`:                     "0",
+		`(repeat "ab" 3)`:           "ababab",
+		`(words " a  b c ")`:        "[a b c]",
+		`(count [["a" 1] ["b" 2]])`: "2",
+		`(invert true)`:             "0",
+		`(index [[2 "b"] [1 "a"]])`: "@[a 1 b 2]",
+		`(type_of repeat)`:          "{string -> int -> string}",
+		`(type_of sum)`:             "{int -> int}",
+		`(type_of count)`:           "{dict -> int}",
+		`(count [])`:                "error: empty",
+		`(repeat "a" 300)`:          "error: repeat argument 1: 300 overflows uint8",
+		`(repeat 1 2)`:              "error: repeat argument 0: cannot convert 1 of type int into string",
+		`(repeat "a")`:              "error: repeat requires 2 arguments",
+	} {
+		got, err := evalWith(extend, program)
+		if err != nil {
+			got = "error: " + err.Error()
+		}
+		if got != want {
+			return fmt.Errorf("%s: want %q got %q", program, want, got)
+		}
+	}
+	return nil
+}
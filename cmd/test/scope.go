@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+)
+
+func init() {
+	register("compiled code resolves names as the tree walker", checkScope)
+}
+
+// checkScope - locals in slots, free names from the closure then from the caller, as in the merged frame of Step
+func checkScope() error {
+	for program, want := range map[string]string{
+		`(let even (lambda n (match n 0 1 (odd (sub n 1)))) odd (lambda n (match n 0 0 (even (sub n 1)))) (even 10))`: "output: 1",
+		`(let f (lambda x (add x y)) g (lambda y (f 1)) (g 5))`:                                                       "output: 6",
+		`(let f (lambda x y) g (lambda y (f 0)) h (lambda y (f 0)) [(g 1) (h 2)])`:                                    "output: [1 2]",
+		`(let x 1 (let x 2 f (lambda y (add x y)) x 3 (f 10)))`:                                                       "output: 12",
+		`(let x 1 f (lambda x (add x 1)) (f 5))`:                                                                      "output: 6",
+		`(let add3 (lambda a b c [a b c]) p (add3 1) q (p 2) (q 3))`:                                                  "output: [1 2 3]",
+		`(let f (lambda (a b) (b 2) [a b]) (f :b 7))`:                                                                 "output: [7 7]",
+		`(let f (lambda (a z) a) (let z 9 (f)))`:                                                                      "output: 9",
+		`(let f (lambda a $ r [a r]) (f 1 2 3))`:                                                                      "output: [1 [2 3]]",
+		`(let a 1 (plet b (add a 1) c (add a 2) [a b c]))`:                                                            "output: [1 2 3]",
+		`(do (def k 5) (def f {x => (add x k)}) (f 1))`:                                                               "output: 6",
+		`(let mk (lambda n (let m (mul n 2) {x => {x + m + n}})) h (mk 3) (h 1))`:                                     "output: 10",
+		`(let k 10 (map [1 2] {x => {x + k}}))`:                                                                       "output: [11 12]",
+		`(let f (lambda l (map l g)) (let g {x => {x * 2}} (f [1 2])))`:                                               "output: [2 4]",
+		`(let g (lambda x (len (filter (names) {n => (match n "secret" 1 0)}))) (let secret 1 (g 0)))`:                "output: 1",
+		`(let count (lambda n (match n 0 0 {1 + (count {n - 1})})) (count 500))`:                                      "output: 500",
+		`(let f (lambda n acc (match n 0 acc (let acc {acc + n} (f {n - 1} acc)))) (f 10 0))`:                         "output: 55",
+		`(let f (lambda x (add x undefined_name)) (f 1))`:                                                             "error: object not found undefined_name",
+	} {
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			if got := runProgram(step, program); got != want {
+				return fmt.Errorf("%s: want %q got %q", program, want, got)
+			}
+		}
+	}
+	return nil
+}
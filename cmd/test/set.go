@@ -0,0 +1,30 @@
+package main
+
+func init() {
+	register("set builtins", checkSet)
+}
+
+func checkSet() error {
+	return checkPrograms(map[string]string{
+		`(set_of 3 1 2 1)`:                                                    "output: set[1 2 3]",
+		`(to_set [[1 2] "a" 1 [1 2]])`:                                        "output: set[1 [1 2] a]",
+		`(to_list (set_of "b" "a"))`:                                          "output: [a b]",
+		`(let s (set_of 1 2) [(has s 1) (has s 3) (len s)])`:                  "output: [1 0 2]",
+		`(let s (set_of 1 2) [(insert s 3) (del s 1) s])`:                     "output: [set[1 2 3] set[2] set[1 2]]",
+		`(union (set_of 1 2) (set_of 2 3) (set_of 5))`:                        "output: set[1 2 3 5]",
+		`(intersect (set_of 1 2 3) (set_of 2 3 4))`:                           "output: set[2 3]",
+		`(difference (set_of 1 2 3 4) (set_of 2) (set_of 4 5))`:               "output: set[1 3]",
+		`[(subset (set_of 1) (set_of 1 2)) (subset (set_of 3) (set_of 1 2))]`: "output: [1 0]",
+		`(match (set_of 1 2) (to_set [2 1 1]) "equal" "different")`:           "output: equal",
+		`(get @[(set_of 1) "found"] (set_of 1))`:                              "output: found",
+		`(union (set_of 1) [1])`:                                              "error: union arguments must be sets",
+		`(intersect (set_of 1) 2)`:                                            "error: intersect arguments must be sets",
+		`(difference [1] (set_of 1))`:                                         "error: difference arguments must be sets",
+		`(subset (set_of 1))`:                                                 "error: subset requires 2 arguments",
+		`(subset (set_of 1) [1])`:                                             "error: subset arguments must be sets",
+		`(set_of print)`:                                                      "error: set element: {builtin: (print 1 2 (lambda x (add x 1))) - print} of type function has no order",
+		`(insert (set_of 1) print)`:                                           "error: insert element: {builtin: (print 1 2 (lambda x (add x 1))) - print} of type function has no order",
+		`(to_set 1)`:                                                          "error: to_set argument must be a list",
+		`[(to_set (range 0 3)) (del (set_of 1) 2)]`:                           "output: [set[0 1 2] set[1]]",
+	})
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"el/parser"
+	"el/runtime"
+	"el/runtime_ext"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+func init() {
+	register("frames survive a snapshot and restore", checkSnapshot)
+}
+
+// snapshotBase - the frame a host builds before it restores a session
+func snapshotBase() (runtime.Runtime, runtime.Frame, error) {
+	r, frame := runtime_ext.NewBasicRuntime(runtime_ext.GrantIO())
+	frame, err := runtime_ext.Register(frame, "twice", func(x int) int { return 2 * x })
+	return r, frame, err
+}
+
+// define - bind the value of each source to its name, as a notebook host does for every cell
+func define(r runtime.Runtime, frame runtime.Frame, definitions [][2]string) (runtime.Frame, error) {
+	for _, d := range definitions {
+		e, _, err := parser.Parse(parser.Tokenize(d[1]))
+		if err != nil {
+			return frame, err
+		}
+		var o runtime.Object
+		if err := r.Step(context.Background(), frame, e).Unwrap(&o); err != nil {
+			return frame, fmt.Errorf("%s: %w", d[0], err)
+		}
+		frame = frame.Set(runtime.Name(d[0]), o)
+	}
+	return frame, nil
+}
+
+func evalIn(r runtime.Runtime, frame runtime.Frame, program string) string {
+	e, _, err := parser.Parse(parser.Tokenize(program))
+	if err != nil {
+		return fmt.Sprintf("parse error: %v", err)
+	}
+	var o runtime.Object
+	if err := r.Step(context.Background(), frame, e).Unwrap(&o); err != nil {
+		return fmt.Sprintf("error: %v", err)
+	}
+	return fmt.Sprintf("output: %v", o)
+}
+
+func checkSnapshot() error {
+	r, base, err := snapshotBase()
+	if err != nil {
+		return err
+	}
+	frame, err := define(r, base, [][2]string{
+		{"+", `add`},
+		{"xs", `[1 "a b" @[1 [2]] (set_of 3 4) nil]`},
+		{"n", `10`},
+		{"make_adder", `(lambda n (lambda x (+ x n)))`},
+		{"add3", `(make_adder 3)`},
+		{"add_n", `((lambda a x (+ x a)) n)`},
+		{"fib", `(lambda k (match (lt k 2) 1 k (+ (fib (sub k 1)) (fib (sub k 2)))))`},
+		{"opt", `(lambda x (y n) $ zs [x y zs])`},
+		{"tw", `twice`},
+		{"typed", `(type_cast (type_chain int_type int_type) add3)`},
+		{"t", `(type_chain int_type list_type)`},
+		{"nested", `(type_chain t (type_chain int_type int_type) int_type)`},
+		{"nothing", `(print)`},
+	})
+	if err != nil {
+		return err
+	}
+	data, err := runtime_ext.Snapshot(frame, base)
+	if err != nil {
+		return err
+	}
+
+	// another process builds the same base and restores the session
+	r, base, err = snapshotBase()
+	if err != nil {
+		return err
+	}
+	restored, err := runtime_ext.Restore(data, base)
+	if err != nil {
+		return err
+	}
+	for program, want := range map[string]string{
+		`xs`:                               "",
+		`[(add3 4) (add_n 1) (fib 10)]`:    "output: [7 11 55]",
+		`[(opt 1) (opt 1 2 3)]`:            "output: [[1 10 []] [1 2 [3]]]",
+		`(make_adder 1)`:                   "",
+		`[(tw 5) (+ 1 2) (typed 1)]`:       "output: [10 3 4]",
+		`[(type_of typed) t (type_of tw)]`: "",
+		`nothing`:                          "output: <nil>",
+		`nested`:                           "",
+	} {
+		before, after := evalIn(r, frame, program), evalIn(r, restored, program)
+		if want != "" && before != want {
+			return fmt.Errorf("%s: want %q got %q", program, want, before)
+		}
+		if after != before {
+			return fmt.Errorf("%s: restored %q differs from %q", program, after, before)
+		}
+	}
+
+	// closures are stored once however many frames capture them
+	frame = base
+	for i := range 30 {
+		if frame, err = define(r, frame, [][2]string{{fmt.Sprintf("f%d", i), `(lambda x x)`}}); err != nil {
+			return err
+		}
+	}
+	if data, err = runtime_ext.Snapshot(frame, base); err != nil {
+		return err
+	}
+	var s struct {
+		Closures []json.RawMessage `json:"closures"`
+	}
+	if err := json.Unmarshal(data, &s); err != nil || len(s.Closures) != 30 {
+		return fmt.Errorf("want 30 closures got %d %v", len(s.Closures), err)
+	}
+
+	for _, c := range []struct {
+		snapshot func() ([]byte, error)
+		base     runtime.Frame
+		want     string
+	}{
+		{func() ([]byte, error) {
+			frame, err := define(r, base, [][2]string{{"c", `(chan)`}})
+			if err != nil {
+				return nil, err
+			}
+			return runtime_ext.Snapshot(frame, base)
+		}, base, "c: cannot snapshot chan{0/0} of type chan"},
+		{func() ([]byte, error) { return []byte(`{"version": 2}`), nil }, base, "snapshot version 2 is not supported"},
+		{func() ([]byte, error) {
+			frame, err := define(r, base, [][2]string{{"tw", `twice`}})
+			if err != nil {
+				return nil, err
+			}
+			return runtime_ext.Snapshot(frame, base)
+		}, runtime.Builtin, "tw: builtin twice is not in the base frame"},
+	} {
+		data, err := c.snapshot()
+		if err == nil {
+			_, err = runtime_ext.Restore(data, c.base)
+		}
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			return fmt.Errorf("want %q got %v", c.want, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+)
+
+func init() {
+	register("special forms cannot be rebound", checkSpecialForms)
+}
+
+func checkSpecialForms() error {
+	for program, want := range map[string]string{
+		`(let match 1 match)`:              "error: cannot rebind special form match",
+		`((lambda lambda 1) 2)`:            "error: cannot rebind special form lambda",
+		`(let x let _ (print "ok") 1)`:     "ok\noutput: 1",
+		`(let l lambda ((l x {x + 1}) 2))`: "output: 3",
+		`(let select 1 select)`:            "error: cannot rebind special form select",
+	} {
+		for _, step := range []stepFunc{treeWalk, compiled} {
+			if got := runProgram(step, program); got != want {
+				return fmt.Errorf("%s: want %q got %q", program, want, got)
+			}
+		}
+	}
+	return nil
+}
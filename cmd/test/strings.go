@@ -0,0 +1,43 @@
+package main
+
+func init() {
+	register("string builtins and interpolation", checkStrings)
+}
+
+func checkStrings() error {
+	return checkPrograms(map[string]string{
+		`(concat "ab" "c" "")`:     "output: abc",
+		`(concat "a" 1)`:           "error: concat argument must be a string",
+		`[(len "héllo") (len "")]`: "output: [5 0]",
+		`[(substring "héllo" 1 3) (substring "héllo" -3 5) (substring "abc" 0 0)]`: "output: [él llo ]",
+		`(substring "abc" 2 1)`:     "error: substring start 2 is after end 1",
+		`(substring "abc" 0 4)`:     "error: substring index 4 out of range for string of length 3",
+		`(split "a,b,,c" ",")`:      "output: [a b  c]",
+		`(join ["a" "b" "c"] ", ")`: "output: a, b, c",
+		`(join ["a" 1] ", ")`:       "error: join elements must be strings",
+		`(replace "a-b-c" "-" "+")`: "output: a+b+c",
+		`[(contains "hello" "ell") (starts_with "hello" "he") (ends_with "hello" "he")]`: "output: [1 1 0]",
+		`[(upper "abc") (lower "ABC") (trim "  x y \n")]`:                                "output: [ABC abc x y]",
+		`[(to_string 1) (to_string [1 "a"]) (to_string "s") (to_string @[1 2])]`:         "output: [1 [1 a] s @[1 2]]",
+		`(add (parse_int " 42 ") (parse_int "-2"))`:                                      "output: 40",
+		`(parse_int "4x")`:                 `error: parse_int: invalid integer "4x"`,
+		`(parse_int 1)`:                    "error: parse_int argument must be a string",
+		`(substring "abc" -4 1)`:           "error: substring index -4 out of range for string of length 3",
+		`(substring "abc" "a" 1)`:          "error: substring index must be an integer",
+		`(substring 1 0 1)`:                "error: substring argument must be a string",
+		`(split "a" 1)`:                    "error: split argument must be a string",
+		`(join "a" ",")`:                   "error: join argument must be a list",
+		`(replace "a" 1 "b")`:              "error: replace argument must be a string",
+		`(contains 1 "a")`:                 "error: contains argument must be a string",
+		`(starts_with "a")`:                "error: starts_with requires 2 arguments",
+		`(trim [1])`:                       "error: trim argument must be a string",
+		`(let name "el" f"hello {name}!")`: "output: hello el!",
+		`(let x 2 f"{x} + 1 = {x + 1}")`:   "output: 2 + 1 = 3",
+		`(let d @["k" [1 2]] f"k={(get d "k")} n={(len (get d "k"))}")`: "output: k=[1 2] n=2",
+		`f"braces \{ \} and \"quotes\""`:                                `output: braces { } and "quotes"`,
+		`[f"" f"plain"]`:                                                "output: [ plain]",
+		`(let f 1 (add f 1))`:                                           "output: 2",
+		`f"a}"`:                                                         `parse error: unmatched } in format string: f"a}"`,
+		`f"{}"`:                                                         `parse error: format string f"{}": empty expression`,
+	})
+}
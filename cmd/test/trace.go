@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"el/ast"
+	"el/parser"
+	"el/runtime"
+	"el/runtime_ext"
+	"el/trace"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+func init() {
+	register("the JSON lines tracer writes one event per call", checkJSONTrace)
+	register("the Chrome tracer writes a trace-event array", checkChromeTrace)
+	register("the profiler writes a pprof profile", checkProfile)
+}
+
+const traceProgram = `
+(def fib (lambda n (match {n <= 1} true n {(fib {n - 1}) + (fib {n - 2})})))
+(fib 5)
+`
+
+// runTraced - run traceProgram with the tracer, 15 calls of fib
+func runTraced(step stepFunc, tracer runtime.Tracer) error {
+	r, frame := runtime_ext.NewBasicRuntime(runtime_ext.GrantIO())
+	m, err := runtime_ext.Template(r, frame)
+	if err != nil {
+		return err
+	}
+	r.Tracer = tracer
+	m.Eval = step
+	var ps parser.State
+	var e ast.Expr
+	var o runtime.Object
+	for tokens := parser.Tokenize(traceProgram); len(tokens) > 0; {
+		if e, tokens, err = ps.Parse(tokens); err != nil {
+			return err
+		}
+		if err := m.Run(r, context.Background(), e).Unwrap(&o); err != nil {
+			return err
+		}
+	}
+	if o.String() != "5" {
+		return fmt.Errorf("want 5 got %s", o)
+	}
+	return nil
+}
+
+func checkJSONTrace() error {
+	for _, step := range []stepFunc{treeWalk, compiled} {
+		var b bytes.Buffer
+		t := trace.NewJSONTracer(&b)
+		if err := runTraced(step, t); err != nil {
+			return err
+		}
+		if err := t.Err(); err != nil {
+			return err
+		}
+		var fibCalls, maxDepth int
+		var last trace.Event
+		scanner := bufio.NewScanner(&b)
+		for scanner.Scan() {
+			if err := json.Unmarshal(scanner.Bytes(), &last); err != nil {
+				return fmt.Errorf("line %q: %v", scanner.Text(), err)
+			}
+			if last.Kind == runtime.CallLambda && last.Name == "fib" {
+				fibCalls++
+				maxDepth = max(maxDepth, last.Depth)
+			}
+		}
+		if fibCalls != 15 {
+			return fmt.Errorf("want 15 calls of fib got %d", fibCalls)
+		}
+		// the outermost call returns last
+		if last.Name != "fib" || last.Depth != 0 || !slices.Equal(last.Args, []string{"5"}) || last.Result != "5" {
+			return fmt.Errorf("unexpected last event %+v", last)
+		}
+		if maxDepth == 0 {
+			return errors.New("nested calls must have a depth")
+		}
+	}
+	return nil
+}
+
+func checkChromeTrace() error {
+	for _, step := range []stepFunc{treeWalk, compiled} {
+		var b bytes.Buffer
+		t := trace.NewChromeTracer(&b)
+		if err := runTraced(step, t); err != nil {
+			return err
+		}
+		if err := t.Close(); err != nil {
+			return err
+		}
+		var events []struct {
+			Name string         `json:"name"`
+			Cat  string         `json:"cat"`
+			Ph   string         `json:"ph"`
+			Ts   float64        `json:"ts"`
+			Dur  float64        `json:"dur"`
+			Args map[string]any `json:"args"`
+		}
+		if err := json.Unmarshal(b.Bytes(), &events); err != nil {
+			return err
+		}
+		fibCalls := 0
+		for _, e := range events {
+			if e.Ph != "X" || e.Ts < 0 || e.Dur < 0 {
+				return fmt.Errorf("unexpected event %+v", e)
+			}
+			if e.Name == "fib" && e.Cat == string(runtime.CallLambda) {
+				fibCalls++
+			}
+		}
+		if fibCalls != 15 {
+			return fmt.Errorf("want 15 calls of fib got %d", fibCalls)
+		}
+	}
+	return nil
+}
+
+// protoField - a field of a protobuf message, the value of a varint or the bytes of a length delimited field
+type protoField struct {
+	num    int
+	varint uint64
+	bytes  []byte
+}
+
+func decodeProto(b []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(b) > 0 {
+		key, n := decodeVarint(b)
+		if n == 0 {
+			return nil, errors.New("bad key")
+		}
+		b = b[n:]
+		f := protoField{num: int(key >> 3)}
+		switch key & 7 {
+		case 0:
+			f.varint, n = decodeVarint(b)
+			if n == 0 {
+				return nil, errors.New("bad varint")
+			}
+			b = b[n:]
+		case 2:
+			size, n := decodeVarint(b)
+			if n == 0 || uint64(len(b)-n) < size {
+				return nil, errors.New("bad length")
+			}
+			f.bytes, b = b[n:n+int(size)], b[n+int(size):]
+		default:
+			return nil, fmt.Errorf("unexpected wire type %d", key&7)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func decodeVarint(b []byte) (uint64, int) {
+	var x uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		x |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i] < 0x80 {
+			return x, i + 1
+		}
+	}
+	return 0, 0
+}
+
+func decodePacked(b []byte) []uint64 {
+	var xs []uint64
+	for len(b) > 0 {
+		x, n := decodeVarint(b)
+		if n == 0 {
+			return nil
+		}
+		xs = append(xs, x)
+		b = b[n:]
+	}
+	return xs
+}
+
+func checkProfile() error {
+	for _, step := range []stepFunc{treeWalk, compiled} {
+		p := trace.NewProfiler()
+		if err := runTraced(step, p); err != nil {
+			return err
+		}
+		var b bytes.Buffer
+		if err := p.WriteProfile(&b); err != nil {
+			return err
+		}
+		zr, err := gzip.NewReader(&b)
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			return err
+		}
+		fields, err := decodeProto(data)
+		if err != nil {
+			return err
+		}
+		var stringTable []string
+		var sampleTypes, functions, samples [][]byte
+		var comments []uint64
+		for _, f := range fields {
+			switch f.num {
+			case 1:
+				sampleTypes = append(sampleTypes, f.bytes)
+			case 2:
+				samples = append(samples, f.bytes)
+			case 5:
+				functions = append(functions, f.bytes)
+			case 6:
+				stringTable = append(stringTable, string(f.bytes))
+			case 10:
+				return errors.New("an instrumented profile has no duration")
+			case 13:
+				comments = append(comments, f.varint)
+			}
+		}
+		var typeNames []string
+		for _, st := range sampleTypes {
+			stFields, err := decodeProto(st)
+			if err != nil {
+				return err
+			}
+			typeNames = append(typeNames, stringTable[stFields[0].varint])
+		}
+		if !slices.Equal(typeNames, []string{"calls", "self_time"}) {
+			return fmt.Errorf("unexpected sample types %v", typeNames)
+		}
+		if len(comments) != 1 || !bytes.Contains([]byte(stringTable[comments[0]]), []byte("instrumented")) {
+			return errors.New("the profile must say it is instrumented")
+		}
+		fibID := uint64(0)
+		for _, fn := range functions {
+			fnFields, err := decodeProto(fn)
+			if err != nil {
+				return err
+			}
+			var id, name uint64
+			for _, f := range fnFields {
+				switch f.num {
+				case 1:
+					id = f.varint
+				case 2:
+					name = f.varint
+				}
+			}
+			if stringTable[name] == "fib" {
+				fibID = id
+			}
+		}
+		if fibID == 0 {
+			return errors.New("no function fib in the profile")
+		}
+		// the location ids are the function ids, the leaf of every stack is the called function
+		var fibCalls uint64
+		for _, sample := range samples {
+			sFields, err := decodeProto(sample)
+			if err != nil {
+				return err
+			}
+			var locations, values []uint64
+			for _, f := range sFields {
+				switch f.num {
+				case 1:
+					locations = decodePacked(f.bytes)
+				case 2:
+					values = decodePacked(f.bytes)
+				}
+			}
+			if len(values) != 2 || len(locations) == 0 {
+				return fmt.Errorf("unexpected sample %v %v", locations, values)
+			}
+			if locations[0] == fibID {
+				fibCalls += values[0]
+			}
+		}
+		if fibCalls != 15 {
+			return fmt.Errorf("want 15 calls of fib got %d", fibCalls)
+		}
+	}
+	return nil
+}
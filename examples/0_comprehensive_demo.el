@@ -34,8 +34,8 @@
     _ (print "Squares:" (map numbers (lambda x {x * x})))
     _ (print "Evens:" (filter numbers (lambda x {x % 2 == 0})))
     _ (print "Odds:" (filter numbers (lambda x {x % 2 == 1})))
-    _ (print "Take 5:" (take 5 numbers))
-    _ (print "Drop 3:" (drop 3 numbers))
+    _ (print "Take 5:" (take numbers 5))
+    _ (print "Drop 3:" (drop numbers 3))
     _ (print "Reverse:" (reverse numbers))
 
     # 5. Functions and Lambdas
@@ -1,7 +1,5 @@
-(let
-    # Hello World - Basic EL Program
-    _ (print "Hello, World!")
-    _ (print "Welcome to the EL programming language!")
+# Hello World - Basic EL Program
+(print "Hello, World!")
+(print "Welcome to the EL programming language!")
 
-    nil
-)
\ No newline at end of file
+nil
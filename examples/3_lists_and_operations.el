@@ -43,8 +43,8 @@
     _ (let
         numbers [1 2 3 4 5]
         _ (print "Reverse:" (reverse numbers))
-        _ (print "Take first 3:" (take 3 numbers))
-        _ (print "Drop first 2:" (drop 2 numbers))
+        _ (print "Take first 3:" (take numbers 3))
+        _ (print "Drop first 2:" (drop numbers 2))
         nil
     )
 
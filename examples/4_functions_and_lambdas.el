@@ -1,101 +1,67 @@
-(let
-    # Functions and Lambda Expressions
-    _ (print "=== Functions and Lambdas ===")
+# Functions and Lambda Expressions
+# a program is a sequence of top level forms, def binds a name for the forms after it
+(print "=== Functions and Lambdas ===")
 
-    # Simple lambda functions
-    _ (let
-        square (lambda n {n * n})
-        _ (print "Square of 5:" (square 5))
-        nil
-    )
+# Simple lambda functions
+(def square (lambda n {n * n}))
+(print "Square of 5:" (square 5))
 
-    # Arrow function syntax
-    _ (let
-        cube {n => {n * n * n}}
-        _ (print "Cube of 3:" (cube 3))
-        nil
-    )
+# Arrow function syntax
+(def cube {n => {n * n * n}})
+(print "Cube of 3:" (cube 3))
 
-    # Multi-parameter functions
-    _ (let
-        add (lambda a b {a + b})
-        _ (print "Add 10 and 20:" (add 10 20))
-        nil
-    )
+# Multi-parameter functions
+(def plus (lambda a b {a + b}))
+(print "Add 10 and 20:" (plus 10 20))
 
-    # Higher-order functions
-    _ (let
-        square (lambda a {a * a})
-        apply_twice (lambda f a (f (f a)))
-        _ (print "Apply square twice to 2:" (apply_twice square 2))
-        nil
-    )
+# Higher-order functions
+(def apply_twice (lambda f a (f (f a))))
+(print "Apply square twice to 2:" (apply_twice square 2))
 
-    # Function composition
-    _ (let
-        square (lambda a {a * a})
-        cube {a => {a * a * a}}
-        compose {f g => {x => (f (g x))}}
-        compose_square_cube (compose square cube)
-        _ (print "Compose square and cube of 2:" (compose_square_cube 2))
-        nil
-    )
+# Function composition
+(def compose {f g => {x => (f (g x))}})
+(def compose_square_cube (compose square cube))
+(print "Compose square and cube of 2:" (compose_square_cube 2))
 
-    # Recursive functions
-    _ (let
-        factorial (lambda n (match {n <= 1}
-            true 1
-            {n * (factorial {n - 1})}
-        ))
-        _ (print "Factorial of 5:" (factorial 5))
-        nil
-    )
+# Recursive functions
+(def factorial (lambda n (match {n <= 1}
+    true 1
+    {n * (factorial {n - 1})}
+)))
+(print "Factorial of 5:" (factorial 5))
 
-    # Fibonacci with memoization concept
-    _ (let
-        fib (lambda n (match {n <= 1}
-            true n
-            (let
-                p (fib {n - 1})
-                q (fib {n - 2})
-                {p + q}
-            )
-        ))
-        _ (print "Fibonacci of 10:" (fib 10))
-        nil
+# Fibonacci with memoization concept
+(def fib (lambda n (match {n <= 1}
+    true n
+    (let
+        p (fib {n - 1})
+        q (fib {n - 2})
+        {p + q}
     )
+)))
+(print "Fibonacci of 10:" (fib 10))
 
-    # Mutual recursion
-    _ (let
-        even (lambda n (match {n <= 0}
-            true true
-            (odd {n - 1})
-        ))
-        odd (lambda n (match {n <= 0}
-            true false
-            (even {n - 1})
-        ))
-        _ (print "Even/Odd test:" [(even 10) (odd 10) (even 11) (odd 11)])
-        nil
-    )
+# Mutual recursion, even refers to odd which is defined after it
+(def even (lambda n (match {n <= 0}
+    true true
+    (odd {n - 1})
+)))
+(def odd (lambda n (match {n <= 0}
+    true false
+    (even {n - 1})
+)))
+(print "Even/Odd test:" [(even 10) (odd 10) (even 11) (odd 11)])
 
-    # Closure example
-    _ (let
-        make_counter (lambda start (lambda {start + 1}))
-        counter (make_counter 0)
-        _ (print "Counter values:" [(counter) (counter) (counter)])
-        nil
-    )
+# Closure example
+(def make_counter (lambda start (lambda {start + 1})))
+(def counter (make_counter 0))
+(print "Counter values:" [(counter) (counter) (counter)])
 
-    # Function that returns functions
-    _ (let
-        make_multiplier (lambda factor (lambda a {a * factor}))
-        double (make_multiplier 2)
-        triple (make_multiplier 3)
-        _ (print "Double 7:" (double 7))
-        _ (print "Triple 7:" (triple 7))
-        nil
-    )
+# Function that returns functions
+(def make_multiplier (lambda factor (lambda a {a * factor})))
+(def double (make_multiplier 2))
+(def triple (make_multiplier 3))
+(print "Double 7:" (double 7))
+(print "Triple 7:" (triple 7))
 
-    nil
-)
+nil
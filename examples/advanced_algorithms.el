@@ -8,9 +8,12 @@ bubble_sort (lambda lst (let
     (match {n <= 1}
         true lst
         (let
-            # One pass of bubble sort
+            # One pass of bubble sort, done when a pass swaps nothing
             sorted_once (bubble_pass lst 0)
-            (bubble_sort sorted_once)
+            (match sorted_once
+                lst lst
+                (bubble_sort sorted_once)
+            )
         )
     )
 ))
@@ -40,16 +43,7 @@ swap (lambda lst i j (let
         true lst
         (match {j >= n}
             true lst
-            (let
-                # Create new list with swapped elements
-                before_i (take i lst)
-                after_i (drop {i + 1} lst)
-                before_j (take j after_i)
-                after_j (drop {j - i} after_i)
-                elem_i (get lst i)
-                elem_j (get lst j)
-                (append (append (append before_i [elem_j]) before_j) (append [elem_i] after_j))
-            )
+            (set (set lst i (get lst j)) j (get lst i))
         )
     )
 ))
@@ -74,7 +68,7 @@ binary_search (lambda lst target (let
                 true mid
                 (match {mid_val > target}
                     true (let
-                        left_half (take mid lst)
+                        left_half (take lst mid)
                         result (binary_search left_half target)
                         (match {result == -1}
                             true -1
@@ -82,7 +76,7 @@ binary_search (lambda lst target (let
                         )
                     )
                     (let
-                        right_half (drop {mid + 1} lst)
+                        right_half (drop lst {mid + 1})
                         result (binary_search right_half target)
                         (match {result == -1}
                             true -1
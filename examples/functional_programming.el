@@ -111,7 +111,7 @@
     # Lazy evaluation simulation
     # Generate infinite sequence (limited by implementation)
     generate_nats (lambda start (cons start (generate_nats {start + 1})))
-    first_10_nats (take 10 (generate_nats 0))
+    first_10_nats (take (generate_nats 0) 10)
     (print "First 10 natural numbers:" first_10_nats)
 
     # Monadic operations (conceptually)
@@ -0,0 +1,28 @@
+package parser
+
+import "fmt"
+
+// Pos - line and column of a token, both start at 1
+type Pos struct {
+	Line int
+	Col  int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
+// Diagnostic - a syntax error, Pos is zero when the tokens were parsed without positions
+type Diagnostic struct {
+	Pos Pos
+	Msg string
+
+	index int // index of the token where the error was found
+}
+
+func (d Diagnostic) Error() string {
+	if d.Pos == (Pos{}) {
+		return d.Msg
+	}
+	return fmt.Sprintf("%s: %s", d.Pos, d.Msg)
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"el/ast"
+	"fmt"
+	"strings"
+)
+
+func isFormatString(token Token) bool {
+	return strings.HasPrefix(token, ast.TokenFormatBeg)
+}
+
+// parseFormatString - desugar string interpolation, the expressions are sugar blocks
+// f"{name} is {age + 1}" -> (concat (to_string name) " is " (to_string {age + 1}))
+// \{ and \} are literal braces
+func (p *parser) parseFormatString(token Token) (ast.Expr, error) {
+	if len(token) < len(ast.TokenFormatBeg)+len(ast.TokenStringEnd) || !strings.HasSuffix(token, ast.TokenStringEnd) {
+		return nil, fmt.Errorf("unterminated format string: %s", token)
+	}
+	body := []rune(token[len(ast.TokenFormatBeg) : len(token)-len(ast.TokenStringEnd)])
+
+	argList := []ast.Expr{ast.Name("concat")}
+	text := ""
+	flushText := func() {
+		if len(text) > 0 {
+			argList = append(argList, ast.Name(ast.TokenStringBeg+text+ast.TokenStringEnd))
+		}
+		text = ""
+	}
+	for i := 0; i < len(body); i++ {
+		ch := body[i]
+		switch {
+		case ch == '\\' && i+1 < len(body):
+			i++
+			if next := string(body[i]); next == ast.TokenSugarBegin || next == ast.TokenSugarEnd {
+				text += next
+			} else {
+				text += string(ch) + next
+			}
+		case string(ch) == ast.TokenSugarBegin:
+			end, err := formatHoleEnd(body, i)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", err, token)
+			}
+			expr, err := p.parseFormatHole(string(body[i+1 : end]))
+			if err != nil {
+				return nil, fmt.Errorf("format string %s: %w", token, err)
+			}
+			flushText()
+			argList = append(argList, ast.Lambda{ast.Name("to_string"), expr})
+			i = end
+		case string(ch) == ast.TokenSugarEnd:
+			return nil, fmt.Errorf("unmatched } in format string: %s", token)
+		default:
+			text += string(ch)
+		}
+	}
+	flushText()
+	switch len(argList) {
+	case 1:
+		return ast.Name(ast.TokenStringBeg + ast.TokenStringEnd), nil
+	case 2:
+		if name, ok := argList[1].(ast.Name); ok {
+			return name, nil // no interpolation
+		}
+	}
+	return ast.Lambda(argList), nil
+}
+
+// formatHoleEnd - the index of the } that closes the { at beg, skipping strings
+func formatHoleEnd(body []rune, beg int) (int, error) {
+	depth := 0
+	inString := false
+	for i := beg; i < len(body); i++ {
+		ch := string(body[i])
+		switch {
+		case inString && ch == `\`:
+			i++
+		case ch == ast.TokenStringBeg:
+			inString = !inString
+		case !inString && ch == ast.TokenSugarBegin:
+			depth++
+		case !inString && ch == ast.TokenSugarEnd:
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unmatched { in format string")
+}
+
+// parseFormatHole - parse an interpolated expression with the operators of the enclosing parse
+func (p *parser) parseFormatHole(source string) (ast.Expr, error) {
+	tokenList := Tokenize(source)
+	if len(tokenList) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	tokenList = append(append([]Token{ast.TokenSugarBegin}, tokenList...), ast.TokenSugarEnd)
+	hole := &parser{tokenList: tokenList, ops: p.ops}
+	expr, err := hole.parse()
+	if err != nil {
+		return nil, err
+	}
+	if hole.i < len(tokenList) {
+		return nil, fmt.Errorf("unexpected tokens after expression: %s", strings.Join(tokenList[hole.i:], " "))
+	}
+	return expr, nil
+}
@@ -3,7 +3,6 @@ package parser
 import (
 	"el/ast"
 	"fmt"
-	"strings"
 	"unicode"
 )
 
@@ -14,101 +13,168 @@ var SplitTokens = map[Token]struct{}{
 	ast.TokenBlockEnd:   {},
 	ast.TokenSugarBegin: {},
 	ast.TokenSugarEnd:   {},
+	ast.TokenListBegin:  {},
+	ast.TokenListEnd:    {},
 	ast.TokenUnwrap:     {},
 	ast.TokenTypeCast:   {},
 }
 
 func Tokenize(s string) []Token {
-	return tokenize(s,
-		SplitTokens,
-		removeComment("#"),
-		mapping(map[string]string{
-			"[": " (list ",
-			"]": " ) ",
-		}),
-	)
-}
-
-type preprocessor func(string) string
-
-var mapping = func(stringMap map[string]string) preprocessor {
-	return func(str string) string {
-		for k, v := range stringMap {
-			str = strings.ReplaceAll(str, k, v)
-		}
-		return str
-	}
-}
-
-var removeComment = func(sep string) preprocessor {
-	return func(str string) string {
-		lines := strings.Split(str, "\n")
-		var newLines []string
-		for _, line := range lines {
-			newLines = append(newLines, strings.SplitN(line, sep, 2)[0])
-		}
-		return strings.Join(newLines, "\n")
-	}
+	tokens, _, _ := tokenize(s, SplitTokens)
+	return tokens
 }
 
-func tokenize(str string, splitToken map[Token]struct{}, pList ...preprocessor) []Token {
-	for _, p := range pList {
-		str = p(str)
-	}
-
+// tokenize - split the source into tokens and their positions, strings and comments are recognized by the same state machine
+// so that brackets and # inside a string are part of the string,
+// a string still open at the end of the source is the last token and has a diagnostic
+func tokenize(str string, splitToken map[Token]struct{}) ([]Token, []Pos, []Diagnostic) {
 	const (
 		STATE_OUTSTRING = iota
 		STATE_INSTRING
 		STATE_INSTRING_ESCAPE
+		STATE_INFORMAT        // inside f"...", depth counts the open braces of interpolated expressions
+		STATE_INFORMAT_ESCAPE // after \ in the text of f"..."
+		STATE_INFORMAT_STRING // inside a string in an interpolated expression
+		STATE_INFORMAT_STRING_ESCAPE
+		STATE_INCOMMENT // from # to the end of the line
 	)
 
 	var tokens []Token
+	var posList []Pos
+	emit := func(token Token, pos Pos) {
+		tokens = append(tokens, token)
+		posList = append(posList, pos)
+	}
 	state := STATE_OUTSTRING
-	buffer := ""
+	depth := 0
+	pos := Pos{Line: 1, Col: 1}
+	buffer, bufferPos := "", pos
+	push := func(ch rune) {
+		if len(buffer) == 0 {
+			bufferPos = pos
+		}
+		buffer += string(ch)
+	}
 	flushBuffer := func() {
 		if len(buffer) > 0 {
-			tokens = append(tokens, buffer)
+			emit(buffer, bufferPos)
 		}
 		buffer = ""
 	}
 	for _, ch := range str {
 		switch state {
 		case STATE_OUTSTRING: // outside string
-			if _, ok := splitToken[Token(ch)]; ok {
-				// split special characters like ( ) [ ] into tokens
+			if string(ch) == ast.TokenComment {
+				// enter comment mode
 				flushBuffer()
-				tokens = append(tokens, string(ch))
+				state = STATE_INCOMMENT
+			} else if buffer+string(ch) == ast.TokenDictBegin {
+				// @[ is one token
+				buffer = ""
+				emit(ast.TokenDictBegin, bufferPos)
+			} else if _, ok := splitToken[Token(ch)]; ok {
+				// split special characters like ( ) [ ] into tokens
 				flushBuffer()
+				emit(string(ch), pos)
 			} else if unicode.IsSpace(ch) {
 				// flush buffer if seeing whitespace
 				flushBuffer()
+			} else if buffer+string(ch) == ast.TokenFormatBeg {
+				// enter format string mode, the whole f"..." is one token
+				push(ch)
+				state = STATE_INFORMAT
 			} else if string(ch) == ast.TokenStringBeg {
 				// enter string mode
 				flushBuffer()
-				buffer += string(ch)
+				push(ch)
 				state = STATE_INSTRING
 			} else {
-				buffer += string(ch)
+				push(ch)
 			}
 		case STATE_INSTRING:
 			if ch == '\\' {
-				buffer += string(ch)
+				push(ch)
 				state = STATE_INSTRING_ESCAPE
 			} else if string(ch) == ast.TokenStringEnd {
 				// exit string mode
-				buffer += string(ch)
+				push(ch)
 				flushBuffer()
 				state = STATE_OUTSTRING
 			} else {
-				buffer += string(ch)
+				push(ch)
 			}
 		case STATE_INSTRING_ESCAPE:
-			buffer += string(ch)
+			push(ch)
 			state = STATE_INSTRING
+		case STATE_INFORMAT:
+			push(ch)
+			switch {
+			case depth == 0 && ch == '\\':
+				state = STATE_INFORMAT_ESCAPE
+			case depth == 0 && string(ch) == ast.TokenStringEnd:
+				// exit format string mode
+				flushBuffer()
+				state = STATE_OUTSTRING
+			case string(ch) == ast.TokenSugarBegin:
+				depth++
+			case depth > 0 && string(ch) == ast.TokenSugarEnd:
+				depth--
+			case depth > 0 && string(ch) == ast.TokenStringBeg:
+				state = STATE_INFORMAT_STRING
+			}
+		case STATE_INFORMAT_ESCAPE:
+			push(ch)
+			state = STATE_INFORMAT
+		case STATE_INFORMAT_STRING:
+			push(ch)
+			if ch == '\\' {
+				state = STATE_INFORMAT_STRING_ESCAPE
+			} else if string(ch) == ast.TokenStringEnd {
+				state = STATE_INFORMAT
+			}
+		case STATE_INFORMAT_STRING_ESCAPE:
+			push(ch)
+			state = STATE_INFORMAT_STRING
+		case STATE_INCOMMENT:
+			if ch == '\n' {
+				state = STATE_OUTSTRING
+			}
 		default:
 			panic(fmt.Sprintf("unreachable state: %d", state))
 		}
+		if ch == '\n' {
+			pos = Pos{Line: pos.Line + 1, Col: 1}
+		} else {
+			pos.Col++
+		}
+	}
+	var diagnostics []Diagnostic
+	if state == STATE_INSTRING || state == STATE_INSTRING_ESCAPE {
+		diagnostics = append(diagnostics, Diagnostic{
+			Pos:   bufferPos,
+			Msg:   fmt.Sprintf("unterminated string: %s", buffer),
+			index: len(tokens),
+		})
 	}
 	flushBuffer()
-	return tokens
+	return tokens, posList, diagnostics
+}
+
+// isUnterminatedString - a string token without its closing quote, for tokens that did not come with their diagnostics
+func isUnterminatedString(token Token) bool {
+	if len(token) == 0 || token[:1] != ast.TokenStringBeg {
+		return false
+	}
+	escaped := false
+	for i, ch := range token[1:] {
+		switch {
+		case escaped:
+			escaped = false
+		case ch == '\\':
+			escaped = true
+		case string(ch) == ast.TokenStringEnd:
+			return i+2 != len(token)
+		}
+	}
+	return true
 }
@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"el/ast"
+	"fmt"
+	"strconv"
+)
+
+type Assoc int
+
+const (
+	AssocLeft Assoc = iota
+	AssocRight
+)
+
+// Operator - precedence and associativity of an infix operator in sugar blocks, higher binds tighter
+type Operator struct {
+	Prec  int
+	Assoc Assoc
+}
+
+// Prefix - a prefix operator in sugar blocks, {- x} -> (neg x)
+type Prefix struct {
+	Prec int
+	Func ast.Name
+}
+
+// DefaultOperator - names that are not in Operators are infix operators that bind tighter than any of them
+var DefaultOperator = Operator{Prec: 90, Assoc: AssocLeft}
+
+// Operators - the infix operators known to the parser, the names are aliases defined by the template
+var Operators = map[ast.Name]Operator{
+	"->": {Prec: 10, Assoc: AssocRight},
+
+	"||": {Prec: 20}, "or": {Prec: 20},
+	"&&": {Prec: 30}, "and": {Prec: 30},
+
+	"==": {Prec: 40}, "!=": {Prec: 40}, "<": {Prec: 40}, "<=": {Prec: 40}, ">": {Prec: 40}, ">=": {Prec: 40},
+	"eq": {Prec: 40}, "ne": {Prec: 40}, "lt": {Prec: 40}, "le": {Prec: 40}, "gt": {Prec: 40}, "ge": {Prec: 40},
+
+	"+": {Prec: 60}, "-": {Prec: 60},
+	"add": {Prec: 60}, "sub": {Prec: 60},
+
+	"*": {Prec: 70}, "x": {Prec: 70}, "/": {Prec: 70}, "%": {Prec: 70},
+	"mul": {Prec: 70}, "div": {Prec: 70}, "mod": {Prec: 70},
+}
+
+// PrefixOperators - operators in the position of an operand, not binds looser than comparisons
+var PrefixOperators = map[ast.Name]Prefix{
+	"-":   {Prec: 100, Func: "neg"},
+	"!":   {Prec: 100, Func: "not"},
+	"not": {Prec: 35, Func: "not"},
+}
+
+// operators - the operator table of one parse, copied on the first infix declaration
+type operators struct {
+	infix  map[ast.Name]Operator
+	copied bool
+}
+
+func (ops *operators) get(name ast.Name) Operator {
+	table := ops.infix
+	if table == nil {
+		table = Operators
+	}
+	if op, ok := table[name]; ok {
+		return op
+	}
+	return DefaultOperator
+}
+
+func (ops *operators) set(name ast.Name, op Operator) {
+	if !ops.copied {
+		table := make(map[ast.Name]Operator, len(Operators))
+		for k, v := range Operators {
+			table[k] = v
+		}
+		ops.infix, ops.copied = table, true
+	}
+	ops.infix[name] = op
+}
+
+// infixDeclaration - (infixl 6 <+> <->) and (infixr 5 ++) declare operators for the rest of the parse
+// the declaration itself is parsed as ()
+func (ops *operators) infixDeclaration(argList []ast.Expr) (bool, error) {
+	if len(argList) == 0 {
+		return false, nil
+	}
+	head, ok := argList[0].(ast.Name)
+	if !ok || (head != "infixl" && head != "infixr") {
+		return false, nil
+	}
+	if len(argList) < 3 {
+		return true, fmt.Errorf("%s requires a precedence and at least 1 operator", head)
+	}
+	prec, err := strconv.Atoi(argList[1].String())
+	if err != nil {
+		return true, fmt.Errorf("%s precedence must be an integer: %s", head, argList[1])
+	}
+	op := Operator{Prec: prec, Assoc: AssocLeft}
+	if head == "infixr" {
+		op.Assoc = AssocRight
+	}
+	for _, arg := range argList[2:] {
+		name, ok := arg.(ast.Name)
+		if !ok {
+			return true, fmt.Errorf("%s operator must be a name: %s", head, arg)
+		}
+		ops.set(name, op)
+	}
+	return true, nil
+}
+
+// processSugar - handles both arithmetic infix and lambda syntax
+// {1 + 2 * 3} -> (+ 1 (* 2 3))
+// {x y => (add x y)} -> (lambda x y (add x y))
+// {x : type1} -> (type.cast type1 x)
+func (ops *operators) processSugar(argList []ast.Expr) (ast.Expr, error) {
+	if len(argList) == 0 {
+		return ast.Lambda(nil), nil
+	}
+	if len(argList) == 1 {
+		return argList[0], nil
+	}
+	secondLastName, ok := argList[len(argList)-2].(ast.Name)
+	if ok && string(secondLastName) == "=>" {
+		// arrow function syntax: {x y => expr}
+		paramList := argList[:len(argList)-2]
+		body := argList[len(argList)-1]
+		lambdaArgList := []ast.Expr{
+			ast.Name("lambda"),
+		}
+		lambdaArgList = append(lambdaArgList, paramList...)
+		lambdaArgList = append(lambdaArgList, body)
+
+		return ast.Lambda(lambdaArgList), nil
+	}
+	if ok && string(secondLastName) == ":" {
+		// type cast syntax
+		typeCastArgList := []ast.Expr{
+			ast.Name("type.cast"),
+			argList[len(argList)-1],
+		}
+		typeCastArgList = append(typeCastArgList, argList[:len(argList)-2]...)
+		return ast.Lambda(typeCastArgList), nil
+	}
+
+	// infix expression, operators and operands alternate
+	s := &infixParser{ops: ops, argList: argList}
+	expr, err := s.parse(0)
+	if err != nil {
+		return nil, err
+	}
+	if s.i < len(argList) {
+		return nil, fmt.Errorf("unexpected %s in infix expression", argList[s.i])
+	}
+	return expr, nil
+}
+
+// infixParser - precedence climbing over the expressions of a sugar block
+type infixParser struct {
+	ops     *operators
+	argList []ast.Expr
+	i       int
+}
+
+// parse - an operand followed by operators of precedence at least minPrec
+func (s *infixParser) parse(minPrec int) (ast.Expr, error) {
+	left, err := s.operand()
+	if err != nil {
+		return nil, err
+	}
+	for s.i < len(s.argList) {
+		name, ok := s.argList[s.i].(ast.Name)
+		if !ok {
+			return nil, fmt.Errorf("operator must be a name: %s", s.argList[s.i])
+		}
+		op := s.ops.get(name)
+		if op.Prec < minPrec {
+			break
+		}
+		s.i++
+		nextPrec := op.Prec + 1
+		if op.Assoc == AssocRight {
+			nextPrec = op.Prec
+		}
+		right, err := s.parse(nextPrec)
+		if err != nil {
+			return nil, err
+		}
+		left = ast.Lambda{name, left, right}
+	}
+	return left, nil
+}
+
+func (s *infixParser) operand() (ast.Expr, error) {
+	if s.i >= len(s.argList) {
+		return nil, fmt.Errorf("missing operand after %s", s.argList[s.i-1])
+	}
+	e := s.argList[s.i]
+	s.i++
+	if name, ok := e.(ast.Name); ok && s.i < len(s.argList) {
+		if prefix, ok := PrefixOperators[name]; ok {
+			operand, err := s.parse(prefix.Prec)
+			if err != nil {
+				return nil, err
+			}
+			return ast.Lambda{prefix.Func, operand}, nil
+		}
+	}
+	return e, nil
+}
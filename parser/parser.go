@@ -2,112 +2,198 @@ package parser
 
 import (
 	"el/ast"
-	"errors"
+	"fmt"
 )
 
-func pop(tokenList []Token) ([]Token, Token, error) {
-	if len(tokenList) == 0 {
-		return nil, "", errors.New("empty token list")
+type Parser = func(tokenList []Token) (ast.Expr, []Token, error)
+
+// closing delimiter of every opening delimiter
+var closers = map[Token]Token{
+	ast.TokenBlockBegin: ast.TokenBlockEnd,
+	ast.TokenSugarBegin: ast.TokenSugarEnd,
+	ast.TokenListBegin:  ast.TokenListEnd,
+	ast.TokenDictBegin:  ast.TokenListEnd,
+}
+
+func isCloser(token Token) bool {
+	return token == ast.TokenBlockEnd || token == ast.TokenSugarEnd || token == ast.TokenListEnd
+}
+
+// parser - a recursive descent parser over a token list, posList is nil if the positions are unknown
+type parser struct {
+	tokenList []Token
+	posList   []Pos
+	lexErrors map[int]Diagnostic // the diagnostics of the lexer by token index
+	i         int
+	ops       operators
+}
+
+func (p *parser) errorf(i int, format string, args ...any) Diagnostic {
+	d := Diagnostic{Msg: fmt.Sprintf(format, args...), index: i}
+	if i < len(p.posList) {
+		d.Pos = p.posList[i]
 	}
-	return tokenList[1:], tokenList[0], nil
+	return d
 }
 
-type Parser = func(tokenList []Token) (ast.Expr, []Token, error)
+// describe - a token and its position for error messages
+func (p *parser) describe(i int) string {
+	if i < len(p.posList) {
+		return fmt.Sprintf("%s at %s", p.tokenList[i], p.posList[i])
+	}
+	return p.tokenList[i]
+}
 
-func parseUntil(parser Parser, stopCond func(ast.Expr) bool, tokenList []Token) ([]ast.Expr, []Token, error) {
-	var arg ast.Expr
-	var err error
-	var argList []ast.Expr
-	for {
-		arg, tokenList, err = parser(tokenList)
-		if err != nil {
-			return nil, tokenList, err
+// Parse - parse the first expression of the token list, return the rest of the tokens
+// operators declared by infixl and infixr are forgotten after the expression, see State
+func Parse(tokenList []Token) (ast.Expr, []Token, error) {
+	return new(State).Parse(tokenList)
+}
+
+// State - the operators declared by infixl and infixr so far,
+// a runner that parses the forms of a program one by one keeps one State for the whole program
+type State struct {
+	ops operators
+}
+
+// Parse - parse the first expression of the token list with the operators declared before it
+func (s *State) Parse(tokenList []Token) (ast.Expr, []Token, error) {
+	p := &parser{tokenList: tokenList, ops: s.ops}
+	expr, err := p.parse()
+	s.ops = p.ops
+	return expr, tokenList[p.i:], err
+}
+
+// ParseAll - parse every top level expression of a source
+// after a syntax error the parser recovers after the delimiter that balances the form with the error,
+// so every error in a file is reported at once
+func ParseAll(source string) ([]ast.Expr, []Diagnostic) {
+	tokenList, posList, lexDiagnostics := tokenize(source, SplitTokens)
+	p := &parser{tokenList: tokenList, posList: posList, lexErrors: map[int]Diagnostic{}}
+	for _, d := range lexDiagnostics {
+		p.lexErrors[d.index] = d
+	}
+	var exprList []ast.Expr
+	var diagnostics []Diagnostic
+	for p.i < len(tokenList) {
+		start := p.i
+		expr, err := p.parse()
+		if err == nil {
+			exprList = append(exprList, expr)
+			continue
 		}
-		if stopCond(arg) {
-			break
+		d := err.(Diagnostic)
+		diagnostics = append(diagnostics, d)
+		p.i = p.recover(start, d.index)
+	}
+	return exprList, diagnostics
+}
+
+// recover - the index to continue parsing at after an error at index i in the expression at start,
+// the token after the delimiter that balances the one at start, whatever its kind,
+// or the next token in the first column if the form is never balanced
+func (p *parser) recover(start int, i int) int {
+	if i == start && isCloser(p.tokenList[i]) {
+		return i + 1 // stray closing delimiter
+	}
+	depth := 0
+	for j := start; j < len(p.tokenList); j++ {
+		if _, ok := closers[p.tokenList[j]]; ok {
+			depth++
+		} else if isCloser(p.tokenList[j]) {
+			depth--
+		}
+		if depth == 0 && j >= i {
+			return j + 1
 		}
-		argList = append(argList, arg)
 	}
-	return argList, tokenList, nil
+	for j := i + 1; j < len(p.tokenList); j++ {
+		if p.posList[j].Col == 1 {
+			return j
+		}
+	}
+	return len(p.tokenList)
 }
 
-func Parse(tokenList []Token) (ast.Expr, []Token, error) {
-	tokenList, head, err := pop(tokenList)
-	if err != nil {
-		return nil, tokenList, err
+func (p *parser) parse() (ast.Expr, error) {
+	if p.i >= len(p.tokenList) {
+		return nil, p.errorf(p.i, "unexpected end of input")
 	}
+	start := p.i
+	head := p.tokenList[p.i]
+	p.i++
 
 	switch head {
 	case ast.TokenBlockBegin:
-		// parse until seeing `)`
-		argList, tokenList, err := parseUntil(Parse, matchName(ast.Name(ast.TokenBlockEnd)), tokenList)
+		argList, err := p.parseUntil(start)
+		if err != nil {
+			return nil, err
+		}
+		if ok, err := p.ops.infixDeclaration(argList); err != nil {
+			return nil, p.errorf(start, "%v", err)
+		} else if ok {
+			return ast.Lambda(nil), nil
+		}
+		return ast.Lambda(argList), nil
+	case ast.TokenListBegin, ast.TokenDictBegin:
+		// [a b] -> (list a b), @[k v] -> (dict k v)
+		argList, err := p.parseUntil(start)
 		if err != nil {
-			return nil, tokenList, err
+			return nil, err
+		}
+		cmd := ast.Name("list")
+		if head == ast.TokenDictBegin {
+			cmd = ast.Name("dict")
 		}
-		return ast.Lambda(argList), tokenList, nil
+		return ast.Lambda(append([]ast.Expr{cmd}, argList...)), nil
 	case ast.TokenSugarBegin:
-		// parse until seeing `}`
-		argList, tokenList, err := parseUntil(Parse, matchName(ast.Name(ast.TokenSugarEnd)), tokenList)
+		argList, err := p.parseUntil(start)
 		if err != nil {
-			return nil, tokenList, err
+			return nil, err
 		}
-		expr, err := processSugar(argList)
-		return expr, tokenList, err
+		expr, err := p.ops.processSugar(argList)
+		if err != nil {
+			return nil, p.errorf(start, "%v", err)
+		}
+		return expr, nil
+	case ast.TokenBlockEnd, ast.TokenSugarEnd, ast.TokenListEnd:
+		return nil, p.errorf(start, "unexpected %s", head)
 	default:
-		return ast.Name(head), tokenList, nil
+		if d, ok := p.lexErrors[start]; ok {
+			return nil, d
+		}
+		if p.lexErrors == nil && isUnterminatedString(head) {
+			return nil, p.errorf(start, "unterminated string: %s", head)
+		}
+		if isFormatString(head) {
+			expr, err := p.parseFormatString(head)
+			if err != nil {
+				return nil, p.errorf(start, "%v", err)
+			}
+			return expr, nil
+		}
+		return ast.Name(head), nil
 	}
 }
 
-// processSugar - handles both arithmetic infix and lambda syntax
-// {1 + 2 + 3} -> (add (add 1 2) 3)
-// {x y => (add x y)} -> (lambda x y (add x y))
-// {x : type1} -> (type.cast type1 x)
-func processSugar(argList []ast.Expr) (ast.Expr, error) {
-	if len(argList) == 0 {
-		return ast.Lambda(nil), nil
-	}
-	if len(argList) == 1 {
-		return argList[0], nil
-	}
-	secondLastName, ok := argList[len(argList)-2].(ast.Name)
-	if ok && string(secondLastName) == "=>" {
-		// arrow function syntax: {x y => expr}
-		paramList := argList[:len(argList)-2]
-		body := argList[len(argList)-1]
-		lambdaArgList := []ast.Expr{
-			ast.Name("lambda"),
-		}
-		lambdaArgList = append(lambdaArgList, paramList...)
-		lambdaArgList = append(lambdaArgList, body)
-
-		return ast.Lambda(lambdaArgList), nil
-	}
-	if ok && string(secondLastName) == ":" {
-		// type cast syntax
-		typeCastArgList := []ast.Expr{
-			ast.Name("type.cast"),
-			argList[len(argList)-1],
-		}
-		typeCastArgList = append(typeCastArgList, argList[:len(argList)-2]...)
-		return ast.Lambda(typeCastArgList), nil
-	}
-
-	// No arrow function or type cast, process as regular infix
-	if ok && string(secondLastName) == "->" {
-		// right to left
-		left, cmd, argList := argList[0], argList[1], argList[1:]
-		right, err := processSugar(argList)
-		if err != nil {
-			return nil, err
+// parseUntil - parse the expressions up to the delimiter that closes the one at start
+func (p *parser) parseUntil(start int) ([]ast.Expr, error) {
+	closer := closers[p.tokenList[start]]
+	var argList []ast.Expr
+	for {
+		if p.i >= len(p.tokenList) {
+			return nil, p.errorf(start, "unclosed %s", p.tokenList[start])
+		}
+		if token := p.tokenList[p.i]; token == closer {
+			p.i++
+			return argList, nil
+		} else if isCloser(token) {
+			return nil, p.errorf(p.i, "%s closed by %s", p.describe(start), token)
 		}
-		return ast.Lambda([]ast.Expr{cmd, left, right}), nil
-	} else {
-		// left to right
-		argList, cmd, right := argList[:len(argList)-2], argList[len(argList)-2], argList[len(argList)-1]
-		left, err := processSugar(argList)
+		arg, err := p.parse()
 		if err != nil {
 			return nil, err
 		}
-		return ast.Lambda([]ast.Expr{cmd, left, right}), nil
+		argList = append(argList, arg)
 	}
 }
@@ -5,8 +5,12 @@ import (
 	"el/ast"
 	"errors"
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
 )
@@ -21,24 +25,115 @@ func init() {
 	Builtin = Builtin.Set("let", MakeData(letFunc, BuiltinType))
 	Builtin = Builtin.Set("match", MakeData(matchFunc, BuiltinType))
 	Builtin = Builtin.Set("lambda", MakeData(lambdaFunc, BuiltinType))
+	Builtin = Builtin.Set("plet", MakeData(pletFunc, BuiltinType))
+	Builtin = Builtin.Set("do", MakeData(doFunc, BuiltinType))
+
+	RegisterSpecialForm("let", letFunc)
+	RegisterSpecialForm("match", matchFunc)
+	RegisterSpecialForm("lambda", lambdaFunc)
+	RegisterSpecialForm("plet", pletFunc)
+	RegisterSpecialForm("do", doFunc)
+}
+
+// specialForms - reserved syntax, recognized by name in the head position of an expression without a frame lookup.
+// the names are also in Builtin so that they can still be used as values, but they cannot be rebound.
+// the map is copied on write, so that it is read without a lock while programs run
+var (
+	specialForms   atomic.Pointer[map[Name]FuncData]
+	specialFormsMu sync.Mutex
+)
+
+// RegisterSpecialForm - make name a special form of every runtime, called from init, e.g. for select of runtime_ext
+func RegisterSpecialForm(name Name, form FuncData) {
+	specialFormsMu.Lock()
+	defer specialFormsMu.Unlock()
+	forms := map[Name]FuncData{}
+	if p := specialForms.Load(); p != nil {
+		maps.Copy(forms, *p)
+	}
+	forms[name] = form
+	specialForms.Store(&forms)
+}
+
+// SpecialForm - the special form named name
+func SpecialForm(name Name) (FuncData, bool) {
+	p := specialForms.Load()
+	if p == nil {
+		return FuncData{}, false
+	}
+	form, ok := (*p)[name]
+	return form, ok
+}
+
+func isSpecialForm(name Name) bool {
+	_, ok := SpecialForm(name)
+	return ok
 }
 
 type Exec = func(r Runtime, ctx context.Context, frame Frame, argExprList []ast.Expr) adt.Result[Object]
 
+// Apply - call a function on evaluated and unwrapped arguments
+type Apply = func(r Runtime, ctx context.Context, frame Frame, argList []Object) adt.Result[Object]
+
 type FuncData struct {
-	Exec Exec
-	Repr string
+	Exec      Exec
+	Apply     Apply // nil for special forms which need the argument expressions
+	Repr      string
+	lambda    *lambda   // nil for builtins
+	applyEnv  applyEnv  // Apply of a lambda, called by compiled code without making the frame of the caller
+	applyTail applyTail // applyEnv without the trace, returning the call in the tail position of the body
+	tail      tailExec  // Exec of a special form with a tail position, returning the call there, e.g. do
+	frameless bool      // Apply does not use the frame, e.g. Extension
+}
+
+// applyEnv - call a lambda with the names visible at the call site
+type applyEnv = func(r Runtime, ctx context.Context, caller env, argList []Object) adt.Result[Object]
+
+// tailCall - a call of a lambda in tail position: the body of a lambda, the last form of do and let, a branch of match.
+// it is returned to the lambda application it is the result of, which makes it in a loop, see runTail,
+// so that a recursion through tail calls runs in constant Go stack. there are none with a Tracer, it sees every call return
+type tailCall struct {
+	apply   applyTail
+	caller  env
+	argList []Object
+}
+
+type applyTail = func(r Runtime, ctx context.Context, caller env, argList []Object) (adt.Result[Object], *tailCall)
+
+type tailExec = func(r Runtime, ctx context.Context, frame Frame, argExprList []ast.Expr) (adt.Result[Object], *tailCall)
+
+// tailForm - a special form from its tail Exec, as an expression that is not in tail position it makes the tail call
+func tailForm(repr string, tail tailExec) FuncData {
+	return FuncData{
+		Repr: repr,
+		Exec: func(r Runtime, ctx context.Context, frame Frame, argExprList []ast.Expr) adt.Result[Object] {
+			o, call := tail(r, ctx, frame, argExprList)
+			return r.runTail(ctx, o, call)
+		},
+		tail: tail,
+	}
+}
+
+// strictExec - Exec of a function that evaluates all of its arguments before the call
+func strictExec(apply Apply) Exec {
+	return func(r Runtime, ctx context.Context, frame Frame, argExprList []ast.Expr) adt.Result[Object] {
+		var argList []Object
+		if err := r.stepAndUnwrapArgs(ctx, frame, argExprList).Unwrap(&argList); err != nil {
+			return resultErr(err)
+		}
+		return apply(r, ctx, frame, argList)
+	}
 }
 
 func (f FuncData) String() string {
 	return f.Repr
 }
 
-var letFunc = FuncData{
-	Repr: "{builtin: (let x 3 4) - assign value 3 to local variable x then return 4}",
-	Exec: func(r Runtime, ctx context.Context, frame Frame, argExprList []ast.Expr) adt.Result[Object] {
+var letFunc = tailForm(
+	"{builtin: (let x 3 4) - assign value 3 to local variable x then return 4}",
+	func(r Runtime, ctx context.Context, frame Frame, argExprList []ast.Expr) (adt.Result[Object], *tailCall) {
 		if len(argExprList) == 0 || len(argExprList)%2 != 1 {
-			return resultErrStrf("let requires at least 1 arguments and odd number of arguments")
+			return resultErrStrf("let requires at least 1 arguments and odd number of arguments"), nil
 		}
 
 		lastExpr := argExprList[len(argExprList)-1]
@@ -51,23 +146,26 @@ var letFunc = FuncData{
 		for lexpr, rexpr := range zip(lExprList, rExprList) {
 			lvalue, ok := lexpr.(ast.Name)
 			if !ok {
-				return resultErrStrf("lvalue must be a Name: %s", lexpr.String())
+				return resultErrStrf("lvalue must be a Name: %s", lexpr.String()), nil
+			}
+			if isSpecialForm(Name(lvalue)) {
+				return resultErr(ErrorRebindSpecialForm(Name(lvalue))), nil
 			}
 			var rvalue Object
 			if err := r.Step(ctx, frame, rexpr).Unwrap(&rvalue); err != nil {
-				return resultErr(err)
+				return resultErr(err), nil
 			}
-			frame = frame.Set(Name(lvalue), rvalue)
+			frame = frame.Set(Name(lvalue), nameFunction(rvalue, Name(lvalue)))
 		}
-		return r.Step(ctx, frame, lastExpr)
+		return r.stepTail(ctx, frame, lastExpr)
 	},
-}
+)
 
-var matchFunc = FuncData{
-	Repr: "{builtin: (match x 1 2 3 4 5) - match, if x=1 then return 2, if x=3 the return 4, otherwise return 5",
-	Exec: func(r Runtime, ctx context.Context, frame Frame, argExprList []ast.Expr) adt.Result[Object] {
+var matchFunc = tailForm(
+	"{builtin: (match x 1 2 3 4 5) - match, if x=1 then return 2, if x=3 the return 4, otherwise return 5",
+	func(r Runtime, ctx context.Context, frame Frame, argExprList []ast.Expr) (adt.Result[Object], *tailCall) {
 		if len(argExprList) < 2 || len(argExprList)%2 != 0 {
-			return resultErrStrf("match requires at least 2 arguments and even number of arguments")
+			return resultErrStrf("match requires at least 2 arguments and even number of arguments"), nil
 		}
 		condExpr := argExprList[0]
 		lastExpr := argExprList[len(argExprList)-1]
@@ -78,26 +176,64 @@ var matchFunc = FuncData{
 		}
 		var cond Object
 		if err := r.Step(ctx, frame, condExpr).Unwrap(&cond); err != nil {
-			return resultErr(err)
+			return resultErr(err), nil
 		}
 
 		for lexpr, rexpr := range zip(lExprList, rExprList) {
 			var comp Object
 			if err := r.Step(ctx, frame, lexpr).Unwrap(&comp); err != nil {
-				return resultErr(err)
+				return resultErr(err), nil
 			}
 			var isEqual bool
 			if err := equal(cond.Data(), comp.Data()).Unwrap(&isEqual); err != nil {
-				return resultErr(err)
+				return resultErr(err), nil
 			}
 			if isEqual {
 				lastExpr = rexpr
 				break
 			}
 		}
-		return r.Step(ctx, frame, lastExpr)
+		return r.stepTail(ctx, frame, lastExpr)
 	},
-}
+)
+
+var doFunc = tailForm(
+	"{builtin: (do (print 1) (def x 2) x) - evaluate in order and return the last, def binds for the rest of the do}",
+	func(r Runtime, ctx context.Context, frame Frame, argExprList []ast.Expr) (adt.Result[Object], *tailCall) {
+		if len(argExprList) == 0 {
+			return resultData(Nil{}, NilType), nil
+		}
+		lastExpr := argExprList[len(argExprList)-1]
+		for _, e := range argExprList[:len(argExprList)-1] {
+			name, valueExpr, isDef, err := parseDef(e)
+			if err != nil {
+				return resultErr(err), nil
+			}
+			if !isDef {
+				valueExpr = e
+			}
+			var value Object
+			if err := r.Step(ctx, frame, valueExpr).Unwrap(&value); err != nil {
+				return resultErr(err), nil
+			}
+			if isDef {
+				frame = frame.Set(name, nameFunction(value, name))
+			}
+		}
+		name, valueExpr, isDef, err := parseDef(lastExpr)
+		if err != nil {
+			return resultErr(err), nil
+		}
+		if isDef {
+			var value Object
+			if err := r.Step(ctx, frame, valueExpr).Unwrap(&value); err != nil {
+				return resultErr(err), nil
+			}
+			return resultObj(nameFunction(value, name)), nil
+		}
+		return r.stepTail(ctx, frame, lastExpr) // the last expression is in tail position, as the body of let
+	},
+)
 
 var lambdaFunc = FuncData{
 	Repr: "{builtin: (lambda x y (add x y) - declare a function}",
@@ -107,80 +243,340 @@ var lambdaFunc = FuncData{
 		}
 
 		lastExpr := argExprList[len(argExprList)-1]
-		paramExprList := argExprList[:len(argExprList)-1]
-
-		paramList := make([]Name, 0, len(argExprList)-1)
-		for _, paramExpr := range paramExprList {
-			lvalue, ok := paramExpr.(ast.Name)
-			if !ok {
-				return resultErrStrf("lvalue must be a Name: %s", paramExpr.String())
-			}
-			paramList = append(paramList, Name(lvalue))
+		paramList, defaults, rest, err := parseParams(argExprList[:len(argExprList)-1])
+		if err != nil {
+			return resultErr(err)
 		}
 
+		if err := Charge(ctx, closureSize(paramList)); err != nil {
+			return resultErr(err)
+		}
 		closure := frame
 		for _, name := range paramList {
 			closure = closure.Del(name) // remove all the parameters from the local
 		}
 
-		return resultObj(makeFunction(paramList, lastExpr, closure))
+		return resultObj(makeFunction(lambda{
+			site:      makeLambdaSite(paramList, defaults, rest, lastExpr),
+			paramList: paramList,
+			defaults:  defaults,
+			rest:      rest,
+			body:      lastExpr,
+			closure:   closure,
+		}))
 	},
 }
 
-func makeFunction(paramList []Name, body ast.Expr, closure Frame) Object {
+// parseParams - the parameters of a lambda: required names x, then optional names with a default expression (y 1),
+// then at most one rest parameter $ z that binds the remaining arguments as a list
+func parseParams(paramExprList []ast.Expr) (paramList []Name, defaults []ast.Expr, rest bool, err error) {
+	for i := 0; i < len(paramExprList); i++ {
+		var lvalue ast.Expr
+		switch p := paramExprList[i].(type) {
+		case ast.Name:
+			switch {
+			case string(p) == ast.TokenUnwrap:
+				if i != len(paramExprList)-2 {
+					return nil, nil, false, fmt.Errorf("rest parameter must be the last parameter: %s", p)
+				}
+				i++
+				rest = true
+				lvalue = paramExprList[i]
+			case len(defaults) > 0:
+				return nil, nil, false, fmt.Errorf("parameter %s without default follows optional parameters", p)
+			default:
+				lvalue = p
+			}
+		case ast.Lambda:
+			if len(p) != 2 {
+				return nil, nil, false, fmt.Errorf("optional parameter must be (name default): %s", p.String())
+			}
+			lvalue = p[0]
+			defaults = append(defaults, p[1])
+		default:
+			lvalue = p
+		}
+		name, ok := lvalue.(ast.Name)
+		if !ok {
+			return nil, nil, false, fmt.Errorf("lvalue must be a Name: %s", lvalue.String())
+		}
+		if isSpecialForm(Name(name)) {
+			return nil, nil, false, ErrorRebindSpecialForm(Name(name))
+		}
+		paramList = append(paramList, Name(name))
+	}
+	return paramList, defaults, rest, nil
+}
+
+// lambda - a user-defined function, kept alongside its FuncData so that let can name it
+type lambda struct {
+	name        Name   // the let name, empty for anonymous functions
+	site        string // where the function was defined, kept across currying
+	paramList   []Name // the required, then the optional, then the rest parameter
+	defaults    []ast.Expr
+	defaultCode []Code // compiled defaults, nil if the function was made by Step
+	rest        bool
+	body        ast.Expr
+	code        tailCode // compiled body, nil if the function was made by Step
+	fn          *fnInfo  // the slots of the compiled body, the parameters before currying come first
+	closure     Frame
+}
+
+// required - the number of arguments before the function is called instead of curried
+func (l lambda) required() int {
+	n := len(l.paramList) - len(l.defaults)
+	if l.rest {
+		n--
+	}
+	return n
+}
+
+func makeFunction(l lambda) Object {
+	applyEnv, applyTail := makeLambdaApply(l)
+	apply := func(r Runtime, ctx context.Context, frame Frame, argList []Object) adt.Result[Object] {
+		return applyEnv(r, ctx, env{frame: frame}, argList)
+	}
 	funcData := FuncData{
-		Repr: makeLambdaRepr(paramList, body, closure),
-		Exec: makeLambdaExec(paramList, body, closure),
+		Repr:      makeLambdaRepr(l),
+		Exec:      strictExec(apply),
+		Apply:     apply,
+		lambda:    &l,
+		applyEnv:  applyEnv,
+		applyTail: applyTail,
 	}
-	funcType := makeWeakestType(len(paramList))
+	funcType := makeWeakestType(l.required())
 	return MakeData(funcData, funcType)
 }
 
-func makeLambdaRepr(paramList []Name, body ast.Expr, closure Frame) string {
-	paramNameList := make([]string, 0, len(paramList))
-	for _, name := range paramList {
-		paramNameList = append(paramNameList, string(name))
+// Closure - the parts of a user-defined function, e.g. to serialize it
+type Closure struct {
+	ID        any        // comparable, the same for every copy of the function value
+	Name      Name       // the let name, empty for anonymous functions
+	ParamList []Name     // the required, then the optional, then the rest parameter
+	Defaults  []ast.Expr // the default expressions of the optional parameters
+	Rest      bool
+	Body      ast.Expr
+	Frame     Frame // the captured frame
+}
+
+// Closure - the parts of a user-defined function, false for builtins
+func (f FuncData) Closure() (Closure, bool) {
+	l := f.lambda
+	if l == nil {
+		return Closure{}, false
 	}
-	return fmt.Sprintf("{closure{...}; %s => %s}", strings.Join(paramNameList, " "), body.String())
+	return Closure{ID: l, Name: l.name, ParamList: l.paramList, Defaults: l.defaults, Rest: l.rest, Body: l.body, Frame: l.closure}, true
 }
 
-func makeLambdaExec(paramList []Name, body ast.Expr, closure Frame) Exec {
-	return func(r Runtime, ctx context.Context, frame Frame, argExprList []ast.Expr) adt.Result[Object] {
+// MakeClosure - a user-defined function from its parts, its body runs with Step
+func MakeClosure(c Closure) Object {
+	return makeFunction(lambda{
+		name:      c.Name,
+		site:      makeLambdaSite(c.ParamList, c.Defaults, c.Rest, c.Body),
+		paramList: c.ParamList,
+		defaults:  c.Defaults,
+		rest:      c.Rest,
+		body:      c.Body,
+		closure:   c.Frame,
+	})
+}
+
+// nameFunction - give an anonymous lambda the name it is bound to by let
+func nameFunction(o Object, name Name) Object {
+	if o == nil {
+		return o
+	}
+	funcData, ok := o.Data().(FuncData)
+	if !ok || funcData.lambda == nil || funcData.lambda.name != "" {
+		return o
+	}
+	l := *funcData.lambda
+	l.name = name
+	return MakeData(makeFunction(l).Data(), o.Type())
+}
+
+func makeLambdaRepr(l lambda) string {
+	paramStrList := make([]string, 0, len(l.paramList))
+	for _, e := range makeParamExprList(l.paramList, l.defaults, l.rest) {
+		paramStrList = append(paramStrList, e.String())
+	}
+	return fmt.Sprintf("{closure{...}; %s => %s}", strings.Join(paramStrList, " "), l.body.String())
+}
+
+// makeParamExprList - the parameter expressions that parseParams reads back
+func makeParamExprList(paramList []Name, defaults []ast.Expr, rest bool) []ast.Expr {
+	exprList := make([]ast.Expr, 0, len(paramList)+1)
+	firstOptional := len(paramList) - len(defaults)
+	if rest {
+		firstOptional--
+	}
+	for i, name := range paramList {
+		switch {
+		case rest && i == len(paramList)-1:
+			exprList = append(exprList, ast.Name(ast.TokenUnwrap), ast.Name(name))
+		case i >= firstOptional:
+			exprList = append(exprList, ast.Lambda{ast.Name(name), defaults[i-firstOptional]})
+		default:
+			exprList = append(exprList, ast.Name(name))
+		}
+	}
+	return exprList
+}
+
+const maxSiteLength = 80
+
+func makeLambdaSite(paramList []Name, defaults []ast.Expr, rest bool, body ast.Expr) string {
+	exprList := []ast.Expr{ast.Name("lambda")}
+	exprList = append(exprList, makeParamExprList(paramList, defaults, rest)...)
+	site := ast.Lambda(append(exprList, body)).String()
+	if len(site) > maxSiteLength {
+		site = site[:maxSiteLength-3] + "..."
+	}
+	return site
+}
+
+// ErrorRestParameter - the runtime cannot make the list of a rest parameter
+var ErrorRestParameter = errors.New("rest parameters require Runtime.MakeList")
+
+// makeLambdaApply - the traced call of a lambda, and the call without the trace that returns the call in tail position of the body
+func makeLambdaApply(l lambda) (applyEnv, applyTail) {
+	paramList, body, closure := l.paramList, l.body, l.closure
+	required := l.required()
+	bound := paramList // the params bound by position or keyword, the rest parameter takes the remaining arguments
+	if l.rest {
+		bound = paramList[:len(paramList)-1]
+	}
+	apply := func(r Runtime, ctx context.Context, caller env, argList []Object) (adt.Result[Object], *tailCall) {
 		/*
-			for recursive function, the name of that function is in `frame`
+			for recursive function, the name of that function is in the frame of the caller
 		*/
+		// the function may be called concurrently, every call extends its own copy of the closure
+		closure := closure
+		argList, keywords := SplitKeywords(argList)
 
-		// 1. evaluate arguments
-		var argList []Object
-		if err := r.stepAndUnwrapArgs(ctx, frame, argExprList).Unwrap(&argList); err != nil {
-			return resultErr(err)
+		// 1. add params to closure, by position then by keyword
+		if len(argList) > len(bound) && !l.rest {
+			return resultErrStrf("too many arguments to lambda"), nil
+		}
+		given := make([]bool, len(bound))
+		for i := range min(len(argList), len(bound)) {
+			closure = closure.Set(bound[i], argList[i])
+			given[i] = true
 		}
-		// 2. add params to closure
-		for param, arg := range zip(paramList, argList) {
-			closure = closure.Set(param, arg)
+		for name, value := range zip(keywords.Names, keywords.Values) {
+			i := slices.Index(bound, name)
+			if i < 0 {
+				return resultErrStrf("unknown keyword argument %s", name), nil
+			}
+			if given[i] {
+				return resultErrStrf("argument %s is given by position and by keyword", name), nil
+			}
+			closure = closure.Set(name, value)
+			given[i] = true
 		}
 
 		// 2. // TODO add type checking here
 
-		if len(argList) > len(paramList) {
-			return resultErrStrf("too many arguments to lambda")
-		} else if len(argList) == len(paramList) {
+		if !slices.Contains(given[:required], false) {
+			makeRest := func() (Object, error) {
+				if !l.rest {
+					return nil, nil
+				}
+				if r.MakeList == nil {
+					return nil, ErrorRestParameter
+				}
+				restList := r.MakeList(argList[min(len(argList), len(bound)):])
+				return restList, Charge(ctx, SizeOf(restList))
+			}
+			if l.code != nil && !slices.Contains(given, false) {
+				// 3. compiled body, the frame of the caller is only merged into the closure if it is needed
+				restList, err := makeRest()
+				if err != nil {
+					return resultErr(err), nil
+				}
+				s := newScope(l.fn, closure, &caller)
+				l.fillParams(s, closure, restList)
+				return l.code(r, ctx, s)
+			}
 			// 3. add environment frame into closure and make call
-			for k, v := range frame.Iter {
+			for k, v := range caller.materialize().Iter {
 				if _, ok := closure.Get(k); !ok {
 					closure = closure.Set(k, v)
 				}
 			}
-			var o Object
-			if err := r.Step(ctx, closure, body).Unwrap(&o); err != nil {
-				return resultErr(err)
+			// 4. missing optional params get their defaults in order, a default sees the params before it
+			for i := required; i < len(bound); i++ {
+				if given[i] {
+					continue
+				}
+				var value Object
+				var result adt.Result[Object]
+				if l.defaultCode != nil {
+					result = l.defaultCode[i-required](r, ctx, closure)
+				} else {
+					result = r.Step(ctx, closure, l.defaults[i-required])
+				}
+				if err := result.Unwrap(&value); err != nil {
+					return resultErr(err), nil
+				}
+				closure = closure.Set(bound[i], value)
+			}
+			restList, err := makeRest()
+			if err != nil {
+				return resultErr(err), nil
 			}
-			return resultObj(o)
+			if l.rest {
+				closure = closure.Set(paramList[len(paramList)-1], restList)
+			}
+			if l.code != nil {
+				s := newScope(l.fn, closure, nil)
+				l.fillParams(s, closure, restList)
+				return l.code(r, ctx, s)
+			}
+			return r.stepTail(ctx, closure, body)
 		} else {
-			// 3. currying
-			return resultObj(makeFunction(paramList[len(argList):], body, closure))
+			// 3. currying, the function waits for the required params that are not given yet
+			curried := l
+			curried.paramList, curried.defaults, curried.defaultCode = nil, nil, nil
+			for i, name := range paramList {
+				if i < len(bound) && given[i] {
+					continue
+				}
+				curried.paramList = append(curried.paramList, name)
+				if i >= required && i < len(bound) {
+					curried.defaults = append(curried.defaults, l.defaults[i-required])
+					if l.defaultCode != nil {
+						curried.defaultCode = append(curried.defaultCode, l.defaultCode[i-required])
+					}
+				}
+			}
+			curried.closure = closure
+			if err := Charge(ctx, closureSize(curried.paramList)); err != nil {
+				return resultErr(err), nil
+			}
+			return resultObj(makeFunction(curried)), nil
 		}
 	}
+	traced := func(r Runtime, ctx context.Context, caller env, argList []Object) adt.Result[Object] {
+		call := Call{Kind: CallLambda, Name: l.name, Site: l.site, Args: argList}
+		return r.traceCall(ctx, call, func(ctx context.Context) adt.Result[Object] {
+			o, tail := apply(r, ctx, caller, argList)
+			return r.runTail(ctx, o, tail)
+		})
+	}
+	return traced, apply
+}
+
+// fillParams - the slots of the parameters of a compiled body, from the frame they are bound in
+// the params given before currying are in the closure, the rest parameter is not in the frame before the merge
+func (l lambda) fillParams(s *scope, frame Frame, restList Object) {
+	for i, name := range l.fn.params {
+		if l.rest && i == len(l.fn.params)-1 {
+			s.slots[i] = restList
+			continue
+		}
+		s.slots[i], _ = frame.Get(name)
+	}
 }
 
 func zip[T1 any, T2 any](l1 []T1, l2 []T2) func(yield func(T1, T2) bool) {
@@ -194,7 +590,18 @@ func zip[T1 any, T2 any](l1 []T1, l2 []T2) func(yield func(T1, T2) bool) {
 	}
 }
 
+// Equaler - data with its own equality in match, e.g. persistent collections
+type Equaler interface {
+	Equal(other Data) bool
+}
+
 func equal(o1 any, o2 any) adt.Result[bool] {
+	if e1, ok := o1.(Equaler); ok {
+		if reflect.TypeOf(o1) != reflect.TypeOf(o2) {
+			return adt.Err[bool](errors.New("match comparison: different types"))
+		}
+		return adt.Ok(e1.Equal(o2.(Data)))
+	}
 	t1 := reflect.TypeOf(o1)
 	t2 := reflect.TypeOf(o2)
 	if t1 == nil || t2 == nil || !t1.Comparable() || !t2.Comparable() {
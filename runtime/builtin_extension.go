@@ -2,7 +2,6 @@ package runtime
 
 import (
 	"context"
-	"el/ast"
 
 	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
 )
@@ -14,21 +13,100 @@ func init() {
 }
 
 type Extension struct {
-	Name Name
-	Man  string
-	Exec func(ctx context.Context, values ...Object) adt.Result[Object]
+	Name     Name
+	Man      string
+	Exec     func(ctx context.Context, values ...Object) adt.Result[Object]
+	Keywords bool // the keyword arguments of a call are passed as the last value, see SplitKeywords
 }
 
 func (ext Extension) Module() FuncData {
-	return FuncData{
-		Repr: ext.Man,
-		Exec: func(r Runtime, ctx context.Context, frame Frame, argExprList []ast.Expr) adt.Result[Object] {
-			var argList []Object
-			if err := r.stepAndUnwrapArgs(ctx, frame, argExprList).Unwrap(&argList); err != nil {
+	apply := func(r Runtime, ctx context.Context, frame Frame, argList []Object) adt.Result[Object] {
+		call := Call{Kind: CallBuiltin, Name: ext.Name, Site: ext.Man, Args: argList}
+		if !ext.Keywords {
+			if err := rejectKeywords(ext.Name, argList); err != nil {
 				return resultErr(err)
 			}
+		}
+		return r.traceCall(ctx, call, func(ctx context.Context) adt.Result[Object] {
 			return ext.Exec(ctx, argList...)
-		},
+		})
+	}
+	return FuncData{
+		Repr:      ext.Man,
+		Exec:      strictExec(apply),
+		Apply:     apply,
+		frameless: true,
+	}
+}
+
+// Caller - call an el function value from inside a builtin
+type Caller = func(ctx context.Context, f Object, args ...Object) adt.Result[Object]
+
+// HigherOrderExtension - an Extension that can call back into el, e.g. map and filter
+type HigherOrderExtension struct {
+	Name Name
+	Man  string
+	Exec func(ctx context.Context, call Caller, values ...Object) adt.Result[Object]
+}
+
+func (ext HigherOrderExtension) Module() FuncData {
+	apply := func(r Runtime, ctx context.Context, frame Frame, argList []Object) adt.Result[Object] {
+		call := Call{Kind: CallBuiltin, Name: ext.Name, Site: ext.Man, Args: argList}
+		if err := rejectKeywords(ext.Name, argList); err != nil {
+			return resultErr(err)
+		}
+		return r.traceCall(ctx, call, func(ctx context.Context) adt.Result[Object] {
+			return ext.Exec(ctx, r.caller(frame), argList...)
+		})
+	}
+	return FuncData{
+		Repr:  ext.Man,
+		Exec:  strictExec(apply),
+		Apply: apply,
+	}
+}
+
+// ParallelExtension - a HigherOrderExtension that can run its calls on the workers of the runtime, e.g. pmap
+type ParallelExtension struct {
+	Name Name
+	Man  string
+	Exec func(ctx context.Context, call Caller, parallel Parallel, values ...Object) adt.Result[Object]
+}
+
+func (ext ParallelExtension) Module() FuncData {
+	apply := func(r Runtime, ctx context.Context, frame Frame, argList []Object) adt.Result[Object] {
+		call := Call{Kind: CallBuiltin, Name: ext.Name, Site: ext.Man, Args: argList}
+		if err := rejectKeywords(ext.Name, argList); err != nil {
+			return resultErr(err)
+		}
+		return r.traceCall(ctx, call, func(ctx context.Context) adt.Result[Object] {
+			return ext.Exec(ctx, r.caller(frame), r.parallel, argList...)
+		})
+	}
+	return FuncData{
+		Repr:  ext.Man,
+		Exec:  strictExec(apply),
+		Apply: apply,
+	}
+}
+
+// caller - apply function values in the frame of the builtin call, checking for interrupts before every call
+func (r Runtime) caller(frame Frame) Caller {
+	return func(ctx context.Context, f Object, args ...Object) adt.Result[Object] {
+		if err := CheckInterrupt(ctx); err != nil {
+			return resultErr(err)
+		}
+		if f == nil {
+			return resultErrStrf("cannot call nil")
+		}
+		funcData, ok := f.Data().(FuncData)
+		if !ok {
+			return resultErrStrf("cannot call %s: not a function", f)
+		}
+		if funcData.Apply == nil {
+			return resultErrStrf("cannot call %s: special forms cannot be called from a builtin", f)
+		}
+		return funcData.Apply(r, ctx, frame, args)
 	}
 }
 
@@ -0,0 +1,435 @@
+package runtime
+
+import (
+	"context"
+	"el/ast"
+	"fmt"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+// Code - an expression compiled into a Go closure, running it gives the same result as Step on the expression
+type Code = func(r Runtime, ctx context.Context, frame Frame) adt.Result[Object]
+
+// scopeCode - compiled code inside a function body, the locals of the body are in slots of the scope
+type scopeCode = func(r Runtime, ctx context.Context, s *scope) adt.Result[Object]
+
+// tailCode - compiled code of an expression in tail position, a call of a lambda is returned instead of made, see tailCall
+type tailCode = func(r Runtime, ctx context.Context, s *scope) (adt.Result[Object], *tailCall)
+
+// Compile - compile an expression ahead of time
+//   - literals are parsed once
+//   - parameters and the names bound by let, do and plet are resolved to slots of the function body,
+//     the other names are looked up once when the body is entered, in the closure then at the call site of the caller
+//   - a call between compiled functions does not merge the frame of the caller into the closure,
+//     the merged frame is only made when it is needed: to capture a closure, for a builtin that calls back into el
+//     and for special forms that are not compiled
+//   - special forms are classified ahead of time, let, match, lambda, plet and do are compiled,
+//     other special forms get their argument expressions as with Step
+//   - arguments of functions with Apply are evaluated by compiled code,
+//     other functions get their argument expressions as with Step
+//   - lambdas made by compiled code run their compiled body, also when called from Step
+//   - calls in tail position are made by the lambda application they are the result of, as with Step
+//   - interrupts are checked on every call instead of every expression
+func (r Runtime) Compile(e ast.Expr) Code {
+	code := r.compileTop(e)
+	return func(r Runtime, ctx context.Context, frame Frame) adt.Result[Object] {
+		return code(r, r.withPool(r.withMeter(ctx)), frame)
+	}
+}
+
+// compileTop - compile an expression that runs in a frame, e.g. a program or the default of a parameter
+func (r Runtime) compileTop(e ast.Expr) Code {
+	fn := newFnInfo(nil)
+	code := r.compile(lexical{fn: fn}, e)
+	return func(r Runtime, ctx context.Context, frame Frame) adt.Result[Object] {
+		return code(r, ctx, newScope(fn, frame, nil))
+	}
+}
+
+func (r Runtime) compile(lex lexical, e ast.Expr) scopeCode {
+	switch e := e.(type) {
+	case ast.Name:
+		return r.compileName(lex, e)
+	case ast.Lambda:
+		return resolveTail(r.compileCall(lex, e, false))
+	default:
+		return errCode(ErrorUnknownExpression(e))
+	}
+}
+
+// compileTail - compile an expression in tail position: the body of a lambda, the last form of do and let, a branch of match
+func (r Runtime) compileTail(lex lexical, e ast.Expr) tailCode {
+	if e, ok := e.(ast.Lambda); ok {
+		return r.compileCall(lex, e, true)
+	}
+	code := r.compile(lex, e)
+	return func(r Runtime, ctx context.Context, s *scope) (adt.Result[Object], *tailCall) {
+		return code(r, ctx, s), nil
+	}
+}
+
+// resolveTail - the code of an expression that is not in tail position, it makes the tail call of its tail code
+func resolveTail(code tailCode) scopeCode {
+	return func(r Runtime, ctx context.Context, s *scope) adt.Result[Object] {
+		o, call := code(r, ctx, s)
+		return r.runTail(ctx, o, call)
+	}
+}
+
+func errCode(err error) scopeCode {
+	return func(r Runtime, ctx context.Context, s *scope) adt.Result[Object] {
+		return resultErr(err)
+	}
+}
+
+func (r Runtime) compileName(lex lexical, e ast.Name) scopeCode {
+	name := Name(e)
+	if slot, ok := lex.lookup(name); ok {
+		return func(r Runtime, ctx context.Context, s *scope) adt.Result[Object] {
+			return resultObj(s.slots[slot])
+		}
+	}
+	i := lex.fn.free(name)
+	var lit Object
+	isLit := r.ParseLiteral(string(name)).Unwrap(&lit) == nil
+	return func(r Runtime, ctx context.Context, s *scope) adt.Result[Object] {
+		if b := s.free[i]; b.ok {
+			return resultObj(b.o)
+		}
+		if isLit {
+			return resultObj(lit)
+		}
+		return resultErr(ErrorNameNotFound(name))
+	}
+}
+
+func (r Runtime) compileList(lex lexical, exprList []ast.Expr) []scopeCode {
+	codeList := make([]scopeCode, 0, len(exprList))
+	for _, e := range exprList {
+		codeList = append(codeList, r.compile(lex, e))
+	}
+	return codeList
+}
+
+// compileCall - a call, in tail position if tail, the tail position of a special form is always compiled as such
+func (r Runtime) compileCall(lex lexical, e ast.Lambda, tail bool) tailCode {
+	var cmd cmd
+	if ok := getCmd(e).Unwrap(&cmd); !ok {
+		return func(r Runtime, ctx context.Context, s *scope) (adt.Result[Object], *tailCall) {
+			return resultData(Nil{}, NilType), nil // empty expression
+		}
+	}
+	if name, ok := cmd.cmdExpr.(ast.Name); ok {
+		if form, ok := SpecialForm(Name(name)); ok {
+			return r.compileSpecialForm(lex, Name(name), form, cmd.argExprList)
+		}
+	}
+	cmdCode := r.compile(lex, cmd.cmdExpr)
+	posExprList, names, valueExprList, keywordErr := splitKeywordExprs(cmd.argExprList)
+	argCodeList := r.compileList(lex, posExprList)
+	valueCodeList := r.compileList(lex, valueExprList)
+	locals := lex.locals
+
+	return func(r Runtime, ctx context.Context, s *scope) (adt.Result[Object], *tailCall) {
+		if err := CheckInterrupt(ctx); err != nil {
+			return resultErr(err), nil
+		}
+		var cmdObject Object
+		if err := cmdCode(r, ctx, s).Unwrap(&cmdObject); err != nil {
+			return resultErr(err), nil
+		}
+		funcData, ok := cmdObject.Data().(FuncData)
+		if !ok {
+			return resultErr(ErrorCannotExecuteExpression(e)), nil
+		}
+		caller := env{scope: s, locals: locals}
+		if funcData.Apply == nil {
+			return funcData.Exec(r, ctx, caller.materialize(), cmd.argExprList), nil
+		}
+		if keywordErr != nil {
+			return resultErr(keywordErr), nil
+		}
+		args := make([]Object, len(argCodeList))
+		for i, argCode := range argCodeList {
+			if err := argCode(r, ctx, s).Unwrap(&args[i]); err != nil {
+				return resultErr(err), nil
+			}
+		}
+		var argList []Object
+		if err := r.UnwrapArgs(ctx, adt.Ok(args)).Unwrap(&argList); err != nil {
+			return resultErr(err), nil
+		}
+		values := make([]Object, len(valueCodeList))
+		for i, valueCode := range valueCodeList {
+			if err := valueCode(r, ctx, s).Unwrap(&values[i]); err != nil {
+				return resultErr(err), nil
+			}
+		}
+		argList = withKeywords(argList, names, values)
+		switch {
+		case tail && funcData.applyTail != nil && r.Tracer == nil:
+			// the scope is done, the caller is flattened so that a recursion does not keep a chain of scopes
+			return adt.Result[Object]{}, &tailCall{apply: funcData.applyTail, caller: env{frame: caller.materialize()}, argList: argList}
+		case funcData.applyEnv != nil:
+			return funcData.applyEnv(r, ctx, caller, argList), nil
+		case funcData.frameless:
+			return funcData.Apply(r, ctx, Frame{}, argList), nil
+		default:
+			return funcData.Apply(r, ctx, caller.materialize(), argList), nil
+		}
+	}
+}
+
+func (r Runtime) compileSpecialForm(lex lexical, name Name, form FuncData, argExprList []ast.Expr) tailCode {
+	var code tailCode
+	switch name {
+	case "let":
+		code = r.compileLet(lex, argExprList)
+	case "match":
+		code = r.compileMatch(lex, argExprList)
+	case "lambda":
+		code = noTail(r.compileLambda(lex, argExprList))
+	case "plet":
+		code = noTail(r.compilePlet(lex, argExprList))
+	case "do":
+		code = r.compileDo(lex, argExprList)
+	default:
+		locals := lex.locals
+		code = func(r Runtime, ctx context.Context, s *scope) (adt.Result[Object], *tailCall) {
+			return form.Exec(r, ctx, env{scope: s, locals: locals}.materialize(), argExprList), nil
+		}
+	}
+	return func(r Runtime, ctx context.Context, s *scope) (adt.Result[Object], *tailCall) {
+		if err := CheckInterrupt(ctx); err != nil {
+			return resultErr(err), nil
+		}
+		return code(r, ctx, s)
+	}
+}
+
+// noTail - the tail code of a special form without a tail position
+func noTail(code scopeCode) tailCode {
+	return func(r Runtime, ctx context.Context, s *scope) (adt.Result[Object], *tailCall) {
+		return code(r, ctx, s), nil
+	}
+}
+
+func (r Runtime) compileLet(lex lexical, argExprList []ast.Expr) tailCode {
+	if len(argExprList) == 0 || len(argExprList)%2 != 1 {
+		return noTail(errCode(fmt.Errorf("let requires at least 1 arguments and odd number of arguments")))
+	}
+	type binding struct {
+		name Name
+		slot int
+		code scopeCode
+		err  error
+	}
+	var bindingList []binding
+	for i := 0; i < len(argExprList)-1; i += 2 {
+		lexpr, rexpr := argExprList[i], argExprList[i+1]
+		lvalue, ok := lexpr.(ast.Name)
+		if !ok {
+			bindingList = append(bindingList, binding{err: fmt.Errorf("lvalue must be a Name: %s", lexpr.String())})
+			break
+		}
+		if isSpecialForm(Name(lvalue)) {
+			bindingList = append(bindingList, binding{err: ErrorRebindSpecialForm(Name(lvalue))})
+			break
+		}
+		code := r.compile(lex, rexpr)
+		var slot int
+		lex, slot = lex.bind(Name(lvalue))
+		bindingList = append(bindingList, binding{name: Name(lvalue), slot: slot, code: code})
+	}
+	lastCode := r.compileTail(lex, argExprList[len(argExprList)-1])
+
+	return func(r Runtime, ctx context.Context, s *scope) (adt.Result[Object], *tailCall) {
+		for _, b := range bindingList {
+			if b.err != nil {
+				return resultErr(b.err), nil
+			}
+			var rvalue Object
+			if err := b.code(r, ctx, s).Unwrap(&rvalue); err != nil {
+				return resultErr(err), nil
+			}
+			s.slots[b.slot] = nameFunction(rvalue, b.name)
+		}
+		return lastCode(r, ctx, s)
+	}
+}
+
+func (r Runtime) compileDo(lex lexical, argExprList []ast.Expr) tailCode {
+	type form struct {
+		name Name // the name bound by a def, empty for other forms
+		slot int
+		code scopeCode
+		tail tailCode // the code of the last form if it is not a def, in tail position
+		err  error
+	}
+	var formList []form
+	for i, e := range argExprList {
+		name, valueExpr, isDef, err := parseDef(e)
+		if err != nil {
+			formList = append(formList, form{err: err})
+			break
+		}
+		if !isDef && i == len(argExprList)-1 {
+			formList = append(formList, form{tail: r.compileTail(lex, e)})
+			continue
+		}
+		if !isDef {
+			formList = append(formList, form{code: r.compile(lex, e)})
+			continue
+		}
+		code := r.compile(lex, valueExpr)
+		var slot int
+		lex, slot = lex.bind(name)
+		formList = append(formList, form{name: name, slot: slot, code: code})
+	}
+
+	return func(r Runtime, ctx context.Context, s *scope) (adt.Result[Object], *tailCall) {
+		if len(formList) == 0 {
+			return resultData(Nil{}, NilType), nil
+		}
+		for i, f := range formList {
+			if f.err != nil {
+				return resultErr(f.err), nil
+			}
+			if f.tail != nil {
+				return f.tail(r, ctx, s)
+			}
+			var value Object
+			if err := f.code(r, ctx, s).Unwrap(&value); err != nil {
+				return resultErr(err), nil
+			}
+			if len(f.name) > 0 {
+				value = nameFunction(value, f.name)
+				s.slots[f.slot] = value
+			}
+			if i == len(formList)-1 {
+				return resultObj(value), nil
+			}
+		}
+		return resultData(Nil{}, NilType), nil
+	}
+}
+
+func (r Runtime) compileMatch(lex lexical, argExprList []ast.Expr) tailCode {
+	if len(argExprList) < 2 || len(argExprList)%2 != 0 {
+		return noTail(errCode(fmt.Errorf("match requires at least 2 arguments and even number of arguments")))
+	}
+	condCode := r.compile(lex, argExprList[0])
+	lastCode := r.compileTail(lex, argExprList[len(argExprList)-1])
+	var lCodeList []scopeCode
+	var rCodeList []tailCode
+	for i := 1; i < len(argExprList)-1; i += 2 {
+		lCodeList = append(lCodeList, r.compile(lex, argExprList[i]))
+		rCodeList = append(rCodeList, r.compileTail(lex, argExprList[i+1]))
+	}
+
+	return func(r Runtime, ctx context.Context, s *scope) (adt.Result[Object], *tailCall) {
+		var cond Object
+		if err := condCode(r, ctx, s).Unwrap(&cond); err != nil {
+			return resultErr(err), nil
+		}
+		for lcode, rcode := range zip(lCodeList, rCodeList) {
+			var comp Object
+			if err := lcode(r, ctx, s).Unwrap(&comp); err != nil {
+				return resultErr(err), nil
+			}
+			var isEqual bool
+			if err := equal(cond.Data(), comp.Data()).Unwrap(&isEqual); err != nil {
+				return resultErr(err), nil
+			}
+			if isEqual {
+				return rcode(r, ctx, s)
+			}
+		}
+		return lastCode(r, ctx, s)
+	}
+}
+
+func (r Runtime) compileLambda(lex lexical, argExprList []ast.Expr) scopeCode {
+	if len(argExprList) < 1 {
+		return errCode(fmt.Errorf("lambda requires at least 1 arguments"))
+	}
+	lastExpr := argExprList[len(argExprList)-1]
+	paramList, defaults, rest, err := parseParams(argExprList[:len(argExprList)-1])
+	if err != nil {
+		return errCode(err)
+	}
+	site := makeLambdaSite(paramList, defaults, rest, lastExpr)
+	// a default sees the parameters given in the call, it runs in the merged frame as with Step
+	defaultCode := make([]Code, 0, len(defaults))
+	for _, e := range defaults {
+		defaultCode = append(defaultCode, r.compileTop(e))
+	}
+	fn := newFnInfo(paramList)
+	bodyLex := lexical{fn: fn}
+	for i, name := range paramList {
+		bodyLex.locals = append(bodyLex.locals, local{name: name, slot: i})
+	}
+	bodyCode := r.compileTail(bodyLex, lastExpr)
+	locals := lex.locals
+
+	return func(r Runtime, ctx context.Context, s *scope) adt.Result[Object] {
+		if err := Charge(ctx, closureSize(paramList)); err != nil {
+			return resultErr(err)
+		}
+		closure := env{scope: s, locals: locals}.materialize()
+		for _, name := range paramList {
+			closure = closure.Del(name) // remove all the parameters from the local
+		}
+		return resultObj(makeFunction(lambda{
+			site:        site,
+			paramList:   paramList,
+			defaults:    defaults,
+			defaultCode: defaultCode,
+			rest:        rest,
+			body:        lastExpr,
+			code:        bodyCode,
+			fn:          fn,
+			closure:     closure,
+		}))
+	}
+}
+
+func (r Runtime) compilePlet(lex lexical, argExprList []ast.Expr) scopeCode {
+	if len(argExprList) == 0 || len(argExprList)%2 != 1 {
+		return errCode(fmt.Errorf("plet requires at least 1 arguments and odd number of arguments"))
+	}
+	var nameList []Name
+	var codeList []scopeCode
+	for i := 0; i < len(argExprList)-1; i += 2 {
+		lvalue, ok := argExprList[i].(ast.Name)
+		if !ok {
+			return errCode(fmt.Errorf("lvalue must be a Name: %s", argExprList[i].String()))
+		}
+		if isSpecialForm(Name(lvalue)) {
+			return errCode(ErrorRebindSpecialForm(Name(lvalue)))
+		}
+		nameList = append(nameList, Name(lvalue))
+		codeList = append(codeList, r.compile(lex, argExprList[i+1]))
+	}
+	// the right hand sides are compiled in the outer scope, they cannot refer to each other
+	slotList := make([]int, len(nameList))
+	for i, name := range nameList {
+		lex, slotList[i] = lex.bind(name)
+	}
+	lastCode := r.compile(lex, argExprList[len(argExprList)-1])
+
+	return func(r Runtime, ctx context.Context, s *scope) adt.Result[Object] {
+		rvalueList := make([]Object, len(codeList))
+		err := r.parallel(ctx, len(codeList), func(ctx context.Context, i int) error {
+			return codeList[i](r, ctx, s).Unwrap(&rvalueList[i])
+		})
+		if err != nil {
+			return resultErr(err)
+		}
+		for i, rvalue := range rvalueList {
+			s.slots[slotList[i]] = nameFunction(rvalue, nameList[i])
+		}
+		return lastCode(r, ctx, s)
+	}
+}
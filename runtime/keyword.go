@@ -0,0 +1,75 @@
+package runtime
+
+import (
+	"el/ast"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Keywords - the keyword arguments of a call (f x :name value), passed to Apply after the positional arguments
+type Keywords struct {
+	Names  []Name
+	Values []Object
+}
+
+func (k Keywords) String() string {
+	ss := make([]string, 0, len(k.Names))
+	for name, value := range zip(k.Names, k.Values) {
+		ss = append(ss, fmt.Sprintf("%s%s %v", ast.TokenTypeCast, name, value))
+	}
+	return strings.Join(ss, " ")
+}
+
+var KeywordsType = MakeType("keywords")
+
+// SplitKeywords - the positional arguments and the keyword arguments of an argument list
+func SplitKeywords(argList []Object) ([]Object, Keywords) {
+	if len(argList) > 0 && argList[len(argList)-1] != nil {
+		if k, ok := argList[len(argList)-1].Data().(Keywords); ok {
+			return argList[:len(argList)-1], k
+		}
+	}
+	return argList, Keywords{}
+}
+
+// splitKeywordExprs - the positional argument expressions and the keyword arguments : name value of a call,
+// keywords may come in any order after or between positional arguments but only once each
+func splitKeywordExprs(argExprList []ast.Expr) (posExprList []ast.Expr, names []Name, valueExprList []ast.Expr, err error) {
+	for i := 0; i < len(argExprList); i++ {
+		if name, ok := argExprList[i].(ast.Name); !ok || string(name) != ast.TokenTypeCast {
+			posExprList = append(posExprList, argExprList[i])
+			continue
+		}
+		if i+2 >= len(argExprList) {
+			return nil, nil, nil, fmt.Errorf("keyword argument must be %sname value", ast.TokenTypeCast)
+		}
+		name, ok := argExprList[i+1].(ast.Name)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("keyword must be a Name: %s", argExprList[i+1].String())
+		}
+		if slices.Contains(names, Name(name)) {
+			return nil, nil, nil, fmt.Errorf("duplicate keyword argument %s", name)
+		}
+		names = append(names, Name(name))
+		valueExprList = append(valueExprList, argExprList[i+2])
+		i += 2
+	}
+	return posExprList, names, valueExprList, nil
+}
+
+// withKeywords - the argument list of a call with its keyword arguments, if any
+func withKeywords(argList []Object, names []Name, values []Object) []Object {
+	if len(names) == 0 {
+		return argList
+	}
+	return append(argList, MakeData(Keywords{Names: names, Values: values}, KeywordsType))
+}
+
+// rejectKeywords - the error of a builtin that takes no keyword arguments
+func rejectKeywords(name Name, argList []Object) error {
+	if _, k := SplitKeywords(argList); len(k.Names) > 0 {
+		return fmt.Errorf("%s does not take keyword arguments", name)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+var ErrorOutOfMemory = errors.New("out of memory")
+
+// Sizer - data with an estimate of its memory in bytes, e.g. 16 bytes per element of a list
+type Sizer interface {
+	Size() int64
+}
+
+// SizeOf - the estimated memory of an object, 0 for data that is not a Sizer
+func SizeOf(o Object) int64 {
+	if o == nil {
+		return 0
+	}
+	if s, ok := o.Data().(Sizer); ok {
+		return s.Size()
+	}
+	return 0
+}
+
+// closureSize - the estimated memory of a function value, its closure shares the frame it captures
+func closureSize(paramList []Name) int64 {
+	return 64 + 16*int64(len(paramList))
+}
+
+// meter - the memory charged to one evaluation, shared by its tasks and workers
+type meter struct {
+	limit int64
+	used  atomic.Int64
+}
+
+type meterKey struct{}
+
+// WithMemoryLimit - charge the allocations of evaluations under ctx against one budget of limit bytes
+func WithMemoryLimit(ctx context.Context, limit int64) context.Context {
+	return context.WithValue(ctx, meterKey{}, &meter{limit: limit})
+}
+
+// withMeter - a budget of Runtime.MemoryLimit for an evaluation that has none yet
+func (r Runtime) withMeter(ctx context.Context) context.Context {
+	if r.MemoryLimit <= 0 {
+		return ctx
+	}
+	if _, ok := ctx.Value(meterKey{}).(*meter); ok {
+		return ctx
+	}
+	return WithMemoryLimit(ctx, r.MemoryLimit)
+}
+
+// Charge - account n bytes to the evaluation under ctx, ErrorOutOfMemory once its budget is exceeded
+// charges are never refunded, the budget limits what an evaluation allocates,
+// a builtin charges the data it makes before it makes it, e.g. 16 bytes for every element of a new list,
+// data it shares with its arguments is not charged again
+func Charge(ctx context.Context, n int64) error {
+	m, ok := ctx.Value(meterKey{}).(*meter)
+	if !ok || n <= 0 {
+		return nil
+	}
+	if m.used.Add(n) > m.limit {
+		return ErrorOutOfMemory
+	}
+	return nil
+}
+
+// MemoryUsed - the bytes charged to the evaluation under ctx
+func MemoryUsed(ctx context.Context) int64 {
+	m, ok := ctx.Value(meterKey{}).(*meter)
+	if !ok {
+		return 0
+	}
+	return m.used.Load()
+}
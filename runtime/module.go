@@ -0,0 +1,81 @@
+package runtime
+
+import (
+	"context"
+	"el/ast"
+	"errors"
+	"fmt"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+func init() {
+	for _, name := range []Name{"def", "define"} {
+		Builtin = Builtin.Set(name, MakeData(defFunc, BuiltinType))
+		RegisterSpecialForm(name, defFunc)
+	}
+}
+
+var ErrorDefNotAllowed = errors.New("def is only allowed at the top level of a program or in do")
+
+// defFunc - (def name value) is run by Module and do, anywhere else it is an error
+var defFunc = FuncData{
+	Repr: "{builtin: (def x 3) - bind x to 3 for the forms after it at the top level of a program or in do}",
+	Exec: func(r Runtime, ctx context.Context, frame Frame, argExprList []ast.Expr) adt.Result[Object] {
+		return resultErr(ErrorDefNotAllowed)
+	},
+}
+
+// parseDef - the name and the value expression of (def name value), false if e is not a def form
+func parseDef(e ast.Expr) (Name, ast.Expr, bool, error) {
+	l, ok := e.(ast.Lambda)
+	if !ok || len(l) == 0 {
+		return "", nil, false, nil
+	}
+	if head, ok := l[0].(ast.Name); !ok || (head != "def" && head != "define") {
+		return "", nil, false, nil
+	}
+	if len(l) != 3 {
+		return "", nil, true, fmt.Errorf("%s requires 2 arguments: %s", l[0], e.String())
+	}
+	lvalue, ok := l[1].(ast.Name)
+	if !ok {
+		return "", nil, true, fmt.Errorf("lvalue must be a Name: %s", l[1].String())
+	}
+	if isSpecialForm(Name(lvalue)) {
+		return "", nil, true, ErrorRebindSpecialForm(Name(lvalue))
+	}
+	return Name(lvalue), l[2], true, nil
+}
+
+// Module - the frame of a program that is a sequence of top level forms,
+// (def name value) adds name to the frame for the forms after it, other forms run for their value, e.g. for print
+type Module struct {
+	Frame Frame
+	Eval  func(r Runtime, ctx context.Context, frame Frame, e ast.Expr) adt.Result[Object] // optional - Runtime.Step if nil
+}
+
+// Run - run a top level form, a def returns the value it binds
+func (m *Module) Run(r Runtime, ctx context.Context, e ast.Expr) adt.Result[Object] {
+	eval := m.Eval
+	if eval == nil {
+		eval = func(r Runtime, ctx context.Context, frame Frame, e ast.Expr) adt.Result[Object] {
+			return r.Step(ctx, frame, e)
+		}
+	}
+	name, valueExpr, isDef, err := parseDef(e)
+	if err != nil {
+		return resultErr(err)
+	}
+	if !isDef {
+		return eval(r, ctx, m.Frame, e)
+	}
+	// the function bound by def finds itself and the later defs in the frame of its callers, as with let
+	var value Object
+	if err := eval(r, ctx, m.Frame, valueExpr).Unwrap(&value); err != nil {
+		return resultErr(err)
+	}
+	value = nameFunction(value, name)
+	m.Frame = m.Frame.Set(name, value)
+	return resultObj(value)
+}
@@ -0,0 +1,136 @@
+package runtime
+
+import (
+	"context"
+	"el/ast"
+	goruntime "runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+// Parallel - run f for every i in [0, n) on the workers of the runtime
+// the error of the lowest i that fails is returned, the calls after it are cancelled
+type Parallel = func(ctx context.Context, n int, f func(ctx context.Context, i int) error) error
+
+// workers - the number of goroutines of one evaluation that run calls of pmap and plet
+func (r Runtime) workers() int {
+	if r.Workers > 0 {
+		return r.Workers
+	}
+	return goruntime.GOMAXPROCS(0)
+}
+
+// pool - the workers of one evaluation beyond the goroutine that started it, shared by nested pmap and plet
+type pool chan struct{}
+
+type poolKey struct{}
+
+// withPool - a pool of Runtime.Workers for an evaluation that has none yet
+func (r Runtime) withPool(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(poolKey{}).(pool); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, poolKey{}, make(pool, r.workers()-1))
+}
+
+// parallel - the calling goroutine is a worker, it starts helpers while the pool of the evaluation has free workers
+// so nested calls never wait for a worker and the evaluation runs on at most Runtime.Workers goroutines
+func (r Runtime) parallel(ctx context.Context, n int, f func(ctx context.Context, i int) error) error {
+	ctx = r.withPool(ctx)
+	p := ctx.Value(poolKey{}).(pool)
+
+	var mu sync.Mutex
+	failed := n // the lowest index that failed
+	var first error
+	cancels := make([]context.CancelFunc, n)
+	var next atomic.Int64
+	work := func() {
+		for {
+			i := int(next.Add(1) - 1)
+			mu.Lock()
+			if i >= failed {
+				mu.Unlock()
+				return
+			}
+			callCtx, cancel := context.WithCancel(ctx)
+			cancels[i] = cancel
+			mu.Unlock()
+
+			err := f(callCtx, i)
+
+			mu.Lock()
+			cancels[i] = nil
+			// the calls before i run to the end, one of them may fail with a lower index
+			if err != nil && i < failed {
+				failed, first = i, err
+				for _, cancel := range cancels[i+1:] {
+					if cancel != nil {
+						cancel()
+					}
+				}
+			}
+			mu.Unlock()
+			cancel()
+		}
+	}
+
+	var wg sync.WaitGroup
+acquire:
+	for range n - 1 {
+		select {
+		case p <- struct{}{}:
+			wg.Go(func() {
+				defer func() { <-p }()
+				work()
+			})
+		default:
+			break acquire
+		}
+	}
+	work()
+	wg.Wait()
+	if first != nil {
+		return first
+	}
+	// the calls may have stopped early because ctx was interrupted
+	return CheckInterrupt(ctx)
+}
+
+var pletFunc = FuncData{
+	Repr: "{builtin: (plet x (f 1) y (g 2) (add x y)) - evaluate (f 1) and (g 2) in parallel, assign them to x and y then return (add x y)}",
+	Exec: func(r Runtime, ctx context.Context, frame Frame, argExprList []ast.Expr) adt.Result[Object] {
+		if len(argExprList) == 0 || len(argExprList)%2 != 1 {
+			return resultErrStrf("plet requires at least 1 arguments and odd number of arguments")
+		}
+
+		lastExpr := argExprList[len(argExprList)-1]
+		var nameList []Name
+		var rExprList []ast.Expr
+		for i := 0; i < len(argExprList)-1; i += 2 {
+			lvalue, ok := argExprList[i].(ast.Name)
+			if !ok {
+				return resultErrStrf("lvalue must be a Name: %s", argExprList[i].String())
+			}
+			if isSpecialForm(Name(lvalue)) {
+				return resultErr(ErrorRebindSpecialForm(Name(lvalue)))
+			}
+			nameList = append(nameList, Name(lvalue))
+			rExprList = append(rExprList, argExprList[i+1])
+		}
+
+		// the right hand sides are evaluated in the outer frame, they cannot refer to each other
+		rvalueList := make([]Object, len(rExprList))
+		err := r.parallel(ctx, len(rExprList), func(ctx context.Context, i int) error {
+			return r.Step(ctx, frame, rExprList[i]).Unwrap(&rvalueList[i])
+		})
+		if err != nil {
+			return resultErr(err)
+		}
+		for name, rvalue := range zip(nameList, rvalueList) {
+			frame = frame.Set(name, nameFunction(rvalue, name))
+		}
+		return r.Step(ctx, frame, lastExpr)
+	},
+}
@@ -17,12 +17,19 @@ type Frame = ordered_map.OrderedMap[Name, Object]
 
 type Runtime struct {
 	ParseLiteral func(lit string) adt.Result[Object]
-	UnwrapArgs   func(argsOpt adt.Result[[]Object]) adt.Result[[]Object]
+	UnwrapArgs   func(ctx context.Context, argsOpt adt.Result[[]Object]) adt.Result[[]Object]
+	MakeList     func(elems []Object) Object // optional - the value of a rest parameter, see ErrorRestParameter
+	Tracer       Tracer                      // optional - observes lambda and builtin calls
+	Workers      int                         // optional - goroutines of one evaluation running pmap and plet, nested calls included, GOMAXPROCS if 0
+	MemoryLimit  int64                       // optional - bytes one evaluation may allocate, see Charge, no limit if 0
 }
 
 var ErrorNameNotFound = func(name Name) error {
 	return fmt.Errorf("object not found %s", name)
 }
+var ErrorRebindSpecialForm = func(name Name) error {
+	return fmt.Errorf("cannot rebind special form %s", name)
+}
 var ErrorInterrupt = errors.New("interrupted")
 var ErrorTimeout = errors.New("timeout")
 
@@ -33,16 +40,46 @@ var ErrorCannotExecuteExpression = func(e ast.Expr) error {
 	return fmt.Errorf("expression cannot be executed: %s", e.String())
 }
 
-func (r Runtime) Step(ctx context.Context, frame Frame, e ast.Expr) adt.Result[Object] {
+// CheckInterrupt - ErrorTimeout or ErrorInterrupt if the context is past its deadline or cancelled
+func CheckInterrupt(ctx context.Context) error {
 	deadline, ok := ctx.Deadline()
 	if ok && time.Now().After(deadline) {
-		return resultErr(ErrorTimeout)
+		return ErrorTimeout
 	}
 	select {
 	case <-ctx.Done():
-		return resultErr(ErrorInterrupt)
+		return ErrorInterrupt
 	default:
 	}
+	return nil
+}
+
+func (r Runtime) Step(ctx context.Context, frame Frame, e ast.Expr) adt.Result[Object] {
+	o, _ := r.step(ctx, frame, e, false)
+	return o
+}
+
+// stepTail - Step of an expression in tail position, a call of a lambda is returned instead of made, see tailCall
+func (r Runtime) stepTail(ctx context.Context, frame Frame, e ast.Expr) (adt.Result[Object], *tailCall) {
+	return r.step(ctx, frame, e, r.Tracer == nil)
+}
+
+// runTail - make the tail call and the tail calls it returns, one after another on the same Go stack frame
+func (r Runtime) runTail(ctx context.Context, o adt.Result[Object], call *tailCall) adt.Result[Object] {
+	for call != nil {
+		if err := CheckInterrupt(ctx); err != nil {
+			return resultErr(err)
+		}
+		o, call = call.apply(r, ctx, call.caller, call.argList)
+	}
+	return o
+}
+
+func (r Runtime) step(ctx context.Context, frame Frame, e ast.Expr, tail bool) (adt.Result[Object], *tailCall) {
+	if err := CheckInterrupt(ctx); err != nil {
+		return resultErr(err), nil
+	}
+	ctx = r.withPool(r.withMeter(ctx))
 
 	/*
 		the whole language is every simple
@@ -52,6 +89,7 @@ func (r Runtime) Step(ctx context.Context, frame Frame, e ast.Expr) adt.Result[O
 				a. lambda: capture the current frame and save the implementation
 				b. let: push a new frame, Exec the function, pop
 				c. match: eval and match
+			special forms are reserved names, they are dispatched before looking up the frame
 
 		only let and function application push a new frame since
 			- let requires local scope to bind new variables
@@ -64,38 +102,73 @@ func (r Runtime) Step(ctx context.Context, frame Frame, e ast.Expr) adt.Result[O
 		name := Name(e)
 		var o Object
 		if ok := r.resolveName(frame, name).Unwrap(&o); !ok {
-			return resultErr(ErrorNameNotFound(name))
+			return resultErr(ErrorNameNotFound(name)), nil
 		}
-		return resultObj(o)
+		return resultObj(o), nil
 	case ast.Lambda:
 		var cmd cmd
 		if ok := getCmd(e).Unwrap(&cmd); !ok {
-			return resultData(Nil{}, NilType) // empty expression
+			return resultData(Nil{}, NilType), nil // empty expression
+		}
+		if name, ok := cmd.cmdExpr.(ast.Name); ok {
+			if form, ok := SpecialForm(Name(name)); ok {
+				if form.tail != nil {
+					// the tail position of the form is in the tail position of the expression
+					o, call := form.tail(r, ctx, frame, cmd.argExprList)
+					if !tail {
+						return r.runTail(ctx, o, call), nil
+					}
+					return o, call
+				}
+				return form.Exec(r, ctx, frame, cmd.argExprList), nil
+			}
 		}
 		var cmdObject Object
 		if err := r.Step(ctx, frame, cmd.cmdExpr).Unwrap(&cmdObject); err != nil {
-			return resultErr(err)
+			return resultErr(err), nil
 		}
 		funcData, ok := cmdObject.Data().(FuncData)
 		if !ok {
-			return resultErr(ErrorCannotExecuteExpression(e))
+			return resultErr(ErrorCannotExecuteExpression(e)), nil
+		}
+		if tail && funcData.applyTail != nil {
+			var argList []Object
+			if err := r.stepAndUnwrapArgs(ctx, frame, cmd.argExprList).Unwrap(&argList); err != nil {
+				return resultErr(err), nil
+			}
+			return adt.Result[Object]{}, &tailCall{apply: funcData.applyTail, caller: env{frame: frame}, argList: argList}
 		}
-		return funcData.Exec(r, ctx, frame, cmd.argExprList)
+		return funcData.Exec(r, ctx, frame, cmd.argExprList), nil
 
 	default:
-		return resultErr(ErrorUnknownExpression(e))
+		return resultErr(ErrorUnknownExpression(e)), nil
 	}
 }
 
-// stepAndUnwrapArgs executes the argument expressions and unwraps the results
+// stepAndUnwrapArgs executes the argument expressions and unwraps the results,
+// keyword arguments are executed after the positional arguments and passed last as Keywords
 func (r Runtime) stepAndUnwrapArgs(ctx context.Context, frame Frame, argExprList []ast.Expr) adt.Result[[]Object] {
-	args := make([]Object, len(argExprList))
-	for i, e := range argExprList {
+	posExprList, names, valueExprList, err := splitKeywordExprs(argExprList)
+	if err != nil {
+		return adt.Err[[]Object](err)
+	}
+	args := make([]Object, len(posExprList))
+	for i, e := range posExprList {
 		if err := r.Step(ctx, frame, e).Unwrap(&args[i]); err != nil {
 			return adt.Err[[]Object](err)
 		}
 	}
-	return r.UnwrapArgs(adt.Ok(args))
+	var argList []Object
+	if err := r.UnwrapArgs(ctx, adt.Ok(args)).Unwrap(&argList); err != nil {
+		return adt.Err[[]Object](err)
+	}
+	values := make([]Object, len(valueExprList))
+	for i, e := range valueExprList {
+		if err := r.Step(ctx, frame, e).Unwrap(&values[i]); err != nil {
+			return adt.Err[[]Object](err)
+		}
+	}
+	return adt.Ok(withKeywords(argList, names, values))
 }
 
 func (r Runtime) resolveName(frame Frame, name Name) adt.Option[Object] {
@@ -0,0 +1,151 @@
+package runtime
+
+import (
+	"sync/atomic"
+)
+
+// fnInfo - the slots of a compiled function body, filled in while it is compiled
+//   - parameters and the names bound by let, do and plet in the body are locals, each has a slot
+//   - the other names are free, they are looked up once when the body is entered
+type fnInfo struct {
+	params    []Name // the first slots, in the order of the parameter list
+	slots     int
+	freeNames []Name
+	freeIndex map[Name]int
+}
+
+func newFnInfo(params []Name) *fnInfo {
+	return &fnInfo{params: params, slots: len(params), freeIndex: map[Name]int{}}
+}
+
+func (fn *fnInfo) free(name Name) int {
+	if i, ok := fn.freeIndex[name]; ok {
+		return i
+	}
+	fn.freeIndex[name] = len(fn.freeNames)
+	fn.freeNames = append(fn.freeNames, name)
+	return len(fn.freeNames) - 1
+}
+
+// local - a name bound to a slot
+type local struct {
+	name Name
+	slot int
+}
+
+// lexical - the function being compiled and the locals in scope, innermost last
+type lexical struct {
+	fn     *fnInfo
+	locals []local
+}
+
+func (lex lexical) lookup(name Name) (int, bool) {
+	for i := len(lex.locals) - 1; i >= 0; i-- {
+		if lex.locals[i].name == name {
+			return lex.locals[i].slot, true
+		}
+	}
+	return 0, false
+}
+
+// bind - a new slot for name, visible to the code compiled with the returned lexical
+func (lex lexical) bind(name Name) (lexical, int) {
+	slot := lex.fn.slots
+	lex.fn.slots++
+	lex.locals = append(lex.locals[:len(lex.locals):len(lex.locals)], local{name: name, slot: slot})
+	return lex, slot
+}
+
+type binding struct {
+	o  Object
+	ok bool
+}
+
+// scope - one run of a compiled function body,
+// it stands for the frame Step would use: frame merged with the frame of the caller, then the locals
+type scope struct {
+	fn        *fnInfo
+	frame     Frame
+	caller    env // names that are not in frame, unless the frame is complete
+	hasCaller bool
+	slots     []Object
+	free      []binding // the free names of fn, resolved when the scope is made
+	merged    atomic.Pointer[Frame]
+}
+
+func newScope(fn *fnInfo, frame Frame, caller *env) *scope {
+	s := &scope{fn: fn, frame: frame}
+	if caller != nil {
+		s.caller, s.hasCaller = *caller, true
+	}
+	s.slots = make([]Object, fn.slots)
+	s.free = make([]binding, len(fn.freeNames))
+	for i, name := range fn.freeNames {
+		o, ok := s.resolve(name)
+		s.free[i] = binding{o: o, ok: ok}
+	}
+	return s
+}
+
+// resolve - look up a name that is not a local, as in the merged frame of Step
+func (s *scope) resolve(name Name) (Object, bool) {
+	if o, ok := s.frame.Get(name); ok {
+		return o, true
+	}
+	if s.hasCaller {
+		return s.caller.lookup(name)
+	}
+	return nil, false
+}
+
+// base - the frame merged with the frame of the caller, made once per scope
+func (s *scope) base() Frame {
+	if !s.hasCaller {
+		return s.frame
+	}
+	if p := s.merged.Load(); p != nil {
+		return *p
+	}
+	frame := s.frame
+	for k, v := range s.caller.materialize().Iter {
+		if _, ok := frame.Get(k); !ok {
+			frame = frame.Set(k, v)
+		}
+	}
+	s.merged.Store(&frame)
+	return frame
+}
+
+// env - the names visible at a point of the program, the frame of Step or a scope with the locals in scope there
+type env struct {
+	scope  *scope
+	locals []local
+	frame  Frame // if scope is nil
+}
+
+func (e env) lookup(name Name) (Object, bool) {
+	if e.scope == nil {
+		return e.frame.Get(name)
+	}
+	for i := len(e.locals) - 1; i >= 0; i-- {
+		if e.locals[i].name == name {
+			return e.scope.slots[e.locals[i].slot], true
+		}
+	}
+	if i, ok := e.scope.fn.freeIndex[name]; ok {
+		return e.scope.free[i].o, e.scope.free[i].ok
+	}
+	return e.scope.resolve(name)
+}
+
+// materialize - the frame Step would have at this point, e.g. for a closure or a special form that is not compiled
+func (e env) materialize() Frame {
+	if e.scope == nil {
+		return e.frame
+	}
+	frame := e.scope.base()
+	for _, l := range e.locals {
+		frame = frame.Set(l.name, e.scope.slots[l.slot])
+	}
+	return frame
+}
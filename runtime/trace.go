@@ -0,0 +1,55 @@
+package runtime
+
+import (
+	"context"
+	"time"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+type CallKind string
+
+const (
+	CallLambda  CallKind = "lambda"
+	CallBuiltin CallKind = "builtin"
+)
+
+// Call - one invocation of a lambda or a builtin, as seen by a Tracer
+type Call struct {
+	Kind     CallKind
+	Name     Name   // let name of the lambda or name of the builtin, empty for anonymous lambdas
+	Site     string // definition site of the lambda, the manual of the builtin
+	Args     []Object
+	Depth    int
+	Start    time.Time
+	Duration time.Duration // set before Exit
+}
+
+// Tracer - observes every lambda and builtin invocation
+// Enter is called after the arguments are evaluated, the returned context is used for the call and passed to Exit
+type Tracer interface {
+	Enter(ctx context.Context, call *Call) context.Context
+	Exit(ctx context.Context, call *Call, o adt.Result[Object])
+}
+
+type callDepthKey struct{}
+
+func callDepth(ctx context.Context) int {
+	depth, _ := ctx.Value(callDepthKey{}).(int)
+	return depth
+}
+
+// traceCall - run exec, reporting it to the tracer if there is one
+func (r Runtime) traceCall(ctx context.Context, call Call, exec func(ctx context.Context) adt.Result[Object]) adt.Result[Object] {
+	if r.Tracer == nil {
+		return exec(ctx)
+	}
+	call.Depth = callDepth(ctx)
+	ctx = context.WithValue(ctx, callDepthKey{}, call.Depth+1)
+	call.Start = time.Now()
+	ctx = r.Tracer.Enter(ctx, &call)
+	o := exec(ctx)
+	call.Duration = time.Since(call.Start)
+	r.Tracer.Exit(ctx, &call, o)
+	return o
+}
@@ -14,10 +14,16 @@ var namesFunc = runtime.FuncData{
 		if len(argExprList) > 0 {
 			return resultErrStrf("names takes no arguments")
 		}
+		if err := chargeList(ctx, 0); err != nil {
+			return resultErr(err)
+		}
 		l := List{}
 
 		for name, _ := range frame.Iter {
 			nameStr := String{Val: string(name)}
+			if err := runtime.Charge(ctx, 16+nameStr.Size()); err != nil {
+				return resultErr(err)
+			}
 			l = List{l.PushBack(makeTypedData(nameStr))}
 		}
 		return resultTypedData(l)
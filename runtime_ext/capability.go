@@ -0,0 +1,161 @@
+package runtime_ext
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+// Capability - a grant of a host resource to scripts, the builtins of a capability are
+// only in the frame of NewBasicRuntime if it is granted, every use of them is audited
+type Capability struct {
+	Name       string
+	Man        string
+	Extensions []Extension
+}
+
+// GrantIO - print, eprint, inspect, read_line and read_all on the streams of the context
+func GrantIO() Capability {
+	return Capability{
+		Name:       "io",
+		Man:        "read stdin and write stdout and stderr of the evaluation",
+		Extensions: []Extension{printExtension, eprintExtension, inspectExtension, readLineExtension, readAllExtension},
+	}
+}
+
+// GrantFSRead - read_file and list_dir under root, paths cannot escape root, also through symlinks
+func GrantFSRead(root string) Capability {
+	return Capability{
+		Name:       "fs",
+		Man:        fmt.Sprintf("read files under %s", root),
+		Extensions: []Extension{makeReadFileExtension(root), makeListDirExtension(root)},
+	}
+}
+
+// GrantClock - now and sleep
+func GrantClock() Capability {
+	return Capability{
+		Name:       "clock",
+		Man:        "read the wall clock and sleep",
+		Extensions: []Extension{nowExtension, sleepExtension},
+	}
+}
+
+// GrantRandom - rand_int
+func GrantRandom() Capability {
+	return Capability{
+		Name:       "random",
+		Man:        "draw random numbers",
+		Extensions: []Extension{randIntExtension},
+	}
+}
+
+// GrantEnv - getenv of the given environment variables
+func GrantEnv(names ...string) Capability {
+	return Capability{
+		Name:       "env",
+		Man:        fmt.Sprintf("read the environment variables %v", names),
+		Extensions: []Extension{makeGetenvExtension(names)},
+	}
+}
+
+// audited - the extension with every call reported to the auditor of the context
+func (c Capability) audited(ext Extension) Extension {
+	exec := ext.Exec
+	ext.Exec = func(ctx context.Context, values ...Object) adt.Result[Object] {
+		o := exec(ctx, values...)
+		AuditorFrom(ctx).Audit(ctx, AuditEvent{
+			Time:       time.Now(),
+			Capability: c.Name,
+			Builtin:    ext.Name,
+			Args:       values,
+			Err:        o.Err,
+		})
+		return o
+	}
+	return ext
+}
+
+// AuditEvent - one use of a capability by a script
+type AuditEvent struct {
+	Time       time.Time
+	Capability string
+	Builtin    Name
+	Args       []Object
+	Err        error // the error of the builtin, nil if it succeeded
+}
+
+func (e AuditEvent) String() string {
+	s := fmt.Sprintf("%s %s %s %v", e.Time.Format(time.RFC3339Nano), e.Capability, e.Builtin, e.Args)
+	if e.Err != nil {
+		s += fmt.Sprintf(" error: %v", e.Err)
+	}
+	return s
+}
+
+// Auditor - observes every use of a capability, it may be called from several goroutines
+type Auditor interface {
+	Audit(ctx context.Context, event AuditEvent)
+}
+
+// AuditFunc - a function as an Auditor
+type AuditFunc func(ctx context.Context, event AuditEvent)
+
+func (f AuditFunc) Audit(ctx context.Context, event AuditEvent) {
+	f(ctx, event)
+}
+
+// AuditLog - an Auditor that keeps every event in memory
+type AuditLog struct {
+	mu     sync.Mutex
+	events []AuditEvent
+}
+
+func (l *AuditLog) Audit(ctx context.Context, event AuditEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+// Events - the events in the order they were audited
+func (l *AuditLog) Events() []AuditEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]AuditEvent(nil), l.events...)
+}
+
+type auditorKey struct{}
+
+// WithAuditor - report the capability uses of evaluations under ctx to the auditor
+func WithAuditor(ctx context.Context, auditor Auditor) context.Context {
+	return context.WithValue(ctx, auditorKey{}, auditor)
+}
+
+// AuditorFrom - the auditor of ctx, capability uses are not recorded by default
+func AuditorFrom(ctx context.Context) Auditor {
+	if auditor, ok := ctx.Value(auditorKey{}).(Auditor); ok {
+		return auditor
+	}
+	return AuditFunc(func(ctx context.Context, event AuditEvent) {})
+}
+
+// makeCapabilitiesExtension - (capabilities) describes what the script is allowed to do
+func makeCapabilitiesExtension(caps []Capability) Extension {
+	return Extension{
+		Name: "capabilities",
+		Man:  "[builtin: (capabilities) - a dict from the names of the granted capabilities to their descriptions]",
+		Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+			if len(values) != 0 {
+				return resultErrStrf("capabilities takes no arguments")
+			}
+			output := Dict{}
+			for _, c := range caps {
+				output = output.Set(makeTypedData(String{Val: c.Name}), makeTypedData(String{Val: c.Man}))
+			}
+			return resultTypedData(output)
+		},
+	}
+}
@@ -0,0 +1,540 @@
+package runtime_ext
+
+import (
+	"context"
+	"el/runtime"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// decoder - convert an el object into a Go value of a fixed type
+type decoder func(o Object) (reflect.Value, error)
+
+// encoder - convert a Go value of a fixed type into an el object
+type encoder func(v reflect.Value) (Object, error)
+
+var (
+	objectType  = reflect.TypeFor[Object]()
+	errorType   = reflect.TypeFor[error]()
+	contextType = reflect.TypeFor[context.Context]()
+)
+
+func makeNil() Object {
+	return runtime.MakeData(runtime.Nil{}, runtime.NilType)
+}
+
+// typeNameOf - the el type name of an object, for error messages
+func typeNameOf(o Object) string {
+	if o == nil {
+		return runtime.Unit
+	}
+	switch data := o.Data().(type) {
+	case TypedData:
+		return data.TypeName()
+	case runtime.FuncData:
+		return "function"
+	case runtime.Nil:
+		return runtime.Unit
+	default:
+		return o.Type().String()
+	}
+}
+
+func errDecode(t reflect.Type, o Object) error {
+	return fmt.Errorf("cannot convert %s of type %s into %s", o, typeNameOf(o), t)
+}
+
+// elTypeName - the el type of the objects a Go type converts to and from
+func elTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Bool:
+		return Int{}.TypeName()
+	case reflect.Float32, reflect.Float64:
+		return Float{}.TypeName()
+	case reflect.String:
+		return String{}.TypeName()
+	case reflect.Slice, reflect.Array:
+		return List{}.TypeName()
+	case reflect.Map, reflect.Struct:
+		return Dict{}.TypeName()
+	case reflect.Pointer:
+		return elTypeName(t.Elem())
+	default:
+		return runtime.Any
+	}
+}
+
+var decoderCache sync.Map // reflect.Type -> decoder
+
+// decoderOf - the cached decoder of a type, recursive types get an indirect decoder while being built
+func decoderOf(t reflect.Type) (decoder, error) {
+	if d, ok := decoderCache.Load(t); ok {
+		return d.(decoder), nil
+	}
+	var wg sync.WaitGroup
+	var d decoder
+	wg.Add(1)
+	indirect, loaded := decoderCache.LoadOrStore(t, decoder(func(o Object) (reflect.Value, error) {
+		wg.Wait()
+		return d(o)
+	}))
+	if loaded {
+		return indirect.(decoder), nil
+	}
+	d, err := newDecoder(t)
+	wg.Done()
+	if err != nil {
+		decoderCache.Delete(t)
+		return nil, err
+	}
+	decoderCache.Store(t, d)
+	return d, nil
+}
+
+func newDecoder(t reflect.Type) (decoder, error) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(o Object) (reflect.Value, error) {
+			i, ok := o.Data().(Int)
+			if !ok {
+				return reflect.Value{}, errDecode(t, o)
+			}
+			v := reflect.New(t).Elem()
+			if v.OverflowInt(int64(i.Val)) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", i.Val, t)
+			}
+			v.SetInt(int64(i.Val))
+			return v, nil
+		}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(o Object) (reflect.Value, error) {
+			i, ok := o.Data().(Int)
+			if !ok {
+				return reflect.Value{}, errDecode(t, o)
+			}
+			v := reflect.New(t).Elem()
+			if i.Val < 0 || v.OverflowUint(uint64(i.Val)) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", i.Val, t)
+			}
+			v.SetUint(uint64(i.Val))
+			return v, nil
+		}, nil
+	case reflect.Bool:
+		return func(o Object) (reflect.Value, error) {
+			i, ok := o.Data().(Int)
+			if !ok {
+				return reflect.Value{}, errDecode(t, o)
+			}
+			v := reflect.New(t).Elem()
+			v.SetBool(i.Val != False.Val)
+			return v, nil
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func(o Object) (reflect.Value, error) {
+			var f float64
+			switch data := o.Data().(type) {
+			case Float:
+				f = data.Val
+			case Int:
+				f = float64(data.Val)
+			default:
+				return reflect.Value{}, errDecode(t, o)
+			}
+			v := reflect.New(t).Elem()
+			v.SetFloat(f)
+			return v, nil
+		}, nil
+	case reflect.String:
+		return func(o Object) (reflect.Value, error) {
+			s, ok := o.Data().(String)
+			if !ok {
+				return reflect.Value{}, errDecode(t, o)
+			}
+			v := reflect.New(t).Elem()
+			v.SetString(s.Val)
+			return v, nil
+		}, nil
+	case reflect.Slice:
+		decodeElem, err := decoderOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return func(o Object) (reflect.Value, error) {
+			l, ok := o.Data().(List)
+			if !ok {
+				return reflect.Value{}, errDecode(t, o)
+			}
+			v := reflect.MakeSlice(t, 0, l.Len())
+			for i, elem := range l.Iter {
+				ev, err := decodeElem(elem)
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("element %d: %w", i, err)
+				}
+				v = reflect.Append(v, ev)
+			}
+			return v, nil
+		}, nil
+	case reflect.Map:
+		decodeKey, err := decoderOf(t.Key())
+		if err != nil {
+			return nil, err
+		}
+		decodeVal, err := decoderOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return func(o Object) (reflect.Value, error) {
+			v := reflect.MakeMap(t)
+			for k, val := range dictEntries(o) {
+				if k == nil {
+					return reflect.Value{}, errDecode(t, o)
+				}
+				kv, err := decodeKey(k)
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("key %v: %w", k, err)
+				}
+				vv, err := decodeVal(val)
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("key %v: %w", k, err)
+				}
+				v.SetMapIndex(kv, vv)
+			}
+			return v, nil
+		}, nil
+	case reflect.Struct:
+		fields, err := fieldsOf(t)
+		if err != nil {
+			return nil, err
+		}
+		return func(o Object) (reflect.Value, error) {
+			d, ok := o.Data().(Dict)
+			if !ok {
+				return reflect.Value{}, errDecode(t, o)
+			}
+			v := reflect.New(t).Elem()
+			for _, f := range fields {
+				val, ok := d.Get(makeTypedData(String{Val: f.name}))
+				if !ok {
+					continue
+				}
+				fv, err := f.decode(val)
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf("field %s: %w", f.name, err)
+				}
+				v.Field(f.index).Set(fv)
+			}
+			return v, nil
+		}, nil
+	case reflect.Pointer:
+		decodeElem, err := decoderOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return func(o Object) (reflect.Value, error) {
+			if _, ok := o.Data().(runtime.Nil); ok {
+				return reflect.Zero(t), nil
+			}
+			ev, err := decodeElem(o)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v := reflect.New(t.Elem())
+			v.Elem().Set(ev)
+			return v, nil
+		}, nil
+	case reflect.Interface:
+		if t == objectType {
+			return func(o Object) (reflect.Value, error) {
+				return reflect.ValueOf(&o).Elem(), nil
+			}, nil
+		}
+		if t.NumMethod() == 0 {
+			return decodeAny, nil
+		}
+	default:
+	}
+	return nil, fmt.Errorf("unsupported Go type %s", t)
+}
+
+// dictEntries - the entries of a dict or of a list of [key value] lists,
+// a nil key is yielded for anything else
+func dictEntries(o Object) func(yield func(key Object, val Object) bool) {
+	return func(yield func(key Object, val Object) bool) {
+		switch data := o.Data().(type) {
+		case Dict:
+			data.Iter(yield)
+		case List:
+			for _, pairObject := range data.Iter {
+				pair, ok := pairObject.Data().(List)
+				if !ok || pair.Len() != 2 {
+					yield(nil, nil)
+					return
+				}
+				if !yield(pair.Get(0), pair.Get(1)) {
+					return
+				}
+			}
+		default:
+			yield(nil, nil)
+		}
+	}
+}
+
+// decodeAny - convert an el object into its natural Go value:
+// int, string, []any, map[string]any if all keys are strings otherwise map[any]any, or the Object itself
+func decodeAny(o Object) (reflect.Value, error) {
+	var out any
+	switch data := o.Data().(type) {
+	case Int:
+		out = data.Val
+	case Float:
+		out = data.Val
+	case String:
+		out = data.Val
+	case List:
+		l := make([]any, 0, data.Len())
+		for i, elem := range data.Iter {
+			ev, err := decodeAny(elem)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %w", i, err)
+			}
+			l = append(l, ev.Interface())
+		}
+		out = l
+	case Dict:
+		m := make(map[any]any, data.Len())
+		stringKeys := true
+		for k, val := range data.Iter {
+			kv, err := decodeAny(k)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			vv, err := decodeAny(val)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("key %v: %w", k, err)
+			}
+			_, isString := kv.Interface().(string)
+			stringKeys = stringKeys && isString
+			m[kv.Interface()] = vv.Interface()
+		}
+		if stringKeys {
+			sm := make(map[string]any, len(m))
+			for k, v := range m {
+				sm[k.(string)] = v
+			}
+			out = sm
+		} else {
+			out = m
+		}
+	case runtime.Nil:
+		out = nil
+	default:
+		out = o
+	}
+	return reflect.ValueOf(&out).Elem(), nil
+}
+
+var encoderCache sync.Map // reflect.Type -> encoder
+
+// encoderOf - the cached encoder of a type, recursive types get an indirect encoder while being built
+func encoderOf(t reflect.Type) (encoder, error) {
+	if e, ok := encoderCache.Load(t); ok {
+		return e.(encoder), nil
+	}
+	var wg sync.WaitGroup
+	var e encoder
+	wg.Add(1)
+	indirect, loaded := encoderCache.LoadOrStore(t, encoder(func(v reflect.Value) (Object, error) {
+		wg.Wait()
+		return e(v)
+	}))
+	if loaded {
+		return indirect.(encoder), nil
+	}
+	e, err := newEncoder(t)
+	wg.Done()
+	if err != nil {
+		encoderCache.Delete(t)
+		return nil, err
+	}
+	encoderCache.Store(t, e)
+	return e, nil
+}
+
+func newEncoder(t reflect.Type) (encoder, error) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value) (Object, error) {
+			i := v.Int()
+			if i < math.MinInt || i > math.MaxInt {
+				return nil, fmt.Errorf("%d overflows int", i)
+			}
+			return makeTypedData(Int{int(i)}), nil
+		}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(v reflect.Value) (Object, error) {
+			i := v.Uint()
+			if i > math.MaxInt {
+				return nil, fmt.Errorf("%d overflows int", i)
+			}
+			return makeTypedData(Int{int(i)}), nil
+		}, nil
+	case reflect.Bool:
+		return func(v reflect.Value) (Object, error) {
+			return makeTypedData(boolToBool(v.Bool())), nil
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func(v reflect.Value) (Object, error) {
+			return makeTypedData(Float{Val: v.Float()}), nil
+		}, nil
+	case reflect.String:
+		return func(v reflect.Value) (Object, error) {
+			return makeTypedData(String{Val: v.String()}), nil
+		}, nil
+	case reflect.Slice, reflect.Array:
+		encodeElem, err := encoderOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) (Object, error) {
+			l := List{}
+			for i := 0; i < v.Len(); i++ {
+				o, err := encodeElem(v.Index(i))
+				if err != nil {
+					return nil, fmt.Errorf("element %d: %w", i, err)
+				}
+				l = List{l.PushBack(o)}
+			}
+			return makeTypedData(l), nil
+		}, nil
+	case reflect.Map:
+		encodeKey, err := encoderOf(t.Key())
+		if err != nil {
+			return nil, err
+		}
+		encodeVal, err := encoderOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) (Object, error) {
+			d := Dict{}
+			for iter := v.MapRange(); iter.Next(); {
+				ko, err := encodeKey(iter.Key())
+				if err != nil {
+					return nil, fmt.Errorf("key %v: %w", iter.Key(), err)
+				}
+				if err := checkOrdered(ko); err != nil {
+					return nil, fmt.Errorf("key %v: %w", iter.Key(), err)
+				}
+				vo, err := encodeVal(iter.Value())
+				if err != nil {
+					return nil, fmt.Errorf("key %v: %w", iter.Key(), err)
+				}
+				d = d.Set(ko, vo)
+			}
+			return makeTypedData(d), nil
+		}, nil
+	case reflect.Struct:
+		fields, err := fieldsOf(t)
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) (Object, error) {
+			d := Dict{}
+			for _, f := range fields {
+				fv := v.Field(f.index)
+				if f.omitEmpty && fv.IsZero() {
+					continue
+				}
+				o, err := f.encode(fv)
+				if err != nil {
+					return nil, fmt.Errorf("field %s: %w", f.name, err)
+				}
+				d = d.Set(makeTypedData(String{Val: f.name}), o)
+			}
+			return makeTypedData(d), nil
+		}, nil
+	case reflect.Pointer:
+		encodeElem, err := encoderOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) (Object, error) {
+			if v.IsNil() {
+				return makeNil(), nil
+			}
+			return encodeElem(v.Elem())
+		}, nil
+	case reflect.Interface:
+		if t == objectType {
+			return func(v reflect.Value) (Object, error) {
+				if v.IsNil() {
+					return makeNil(), nil
+				}
+				return v.Interface().(Object), nil
+			}, nil
+		}
+		return func(v reflect.Value) (Object, error) {
+			if v.IsNil() {
+				return makeNil(), nil
+			}
+			encode, err := encoderOf(v.Elem().Type())
+			if err != nil {
+				return nil, err
+			}
+			return encode(v.Elem())
+		}, nil
+	default:
+	}
+	return nil, fmt.Errorf("unsupported Go type %s", t)
+}
+
+// field - a struct field converted to and from a dict entry
+type field struct {
+	name      string
+	index     int
+	omitEmpty bool
+	encode    encoder
+	decode    decoder
+}
+
+// fieldsOf - the exported fields of a struct, named by their `el:"name,omitempty"` tag or their Go name,
+// fields tagged `el:"-"` are skipped
+func fieldsOf(t reflect.Type) ([]field, error) {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag := sf.Tag.Get("el")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if len(name) == 0 {
+			name = sf.Name
+		}
+		encode, err := encoderOf(sf.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", sf.Name, err)
+		}
+		decode, err := decoderOf(sf.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", sf.Name, err)
+		}
+		fields = append(fields, field{
+			name:      name,
+			index:     i,
+			omitEmpty: opts == "omitempty",
+			encode:    encode,
+			decode:    decode,
+		})
+	}
+	return fields, nil
+}
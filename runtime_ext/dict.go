@@ -0,0 +1,83 @@
+package runtime_ext
+
+import (
+	"el/runtime"
+	"fmt"
+	"strings"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/persistent/ordered_map"
+)
+
+// Dict - persistent map from ordered objects to objects
+type Dict struct {
+	entries ordered_map.Map[dictEntry]
+}
+
+type dictEntry struct {
+	key Object
+	val Object
+}
+
+func (e dictEntry) Cmp(o dictEntry) int {
+	return compareObject(e.key, o.key)
+}
+
+func (d Dict) Get(key Object) (Object, bool) {
+	entry, ok := d.entries.Get(dictEntry{key: key})
+	if !ok {
+		return nil, false
+	}
+	return entry.(dictEntry).val, true
+}
+
+// Set - the key must be ordered, see checkOrdered
+func (d Dict) Set(key Object, val Object) Dict {
+	return Dict{entries: d.entries.Set(dictEntry{key: key, val: val})}
+}
+
+func (d Dict) Del(key Object) Dict {
+	return Dict{entries: d.entries.Del(dictEntry{key: key})}
+}
+
+func (d Dict) Len() int {
+	return int(d.entries.Len())
+}
+
+// Iter - iterate over the entries in key order
+func (d Dict) Iter(yield func(key Object, val Object) bool) {
+	d.entries.Iter(func(entry dictEntry) bool {
+		return yield(entry.key, entry.val)
+	})
+}
+
+// items - the entries as a list of [key value] lists
+func (d Dict) items() List {
+	l := List{}
+	for k, v := range d.Iter {
+		l = List{l.PushBack(makeTypedData(List{List{}.PushBack(k, v)}))}
+	}
+	return l
+}
+
+func (d Dict) String() string {
+	ls := make([]string, 0, 2*d.Len())
+	for k, v := range d.Iter {
+		ls = append(ls, fmt.Sprint(k), fmt.Sprint(v))
+	}
+	return fmt.Sprintf("@[%s]", strings.Join(ls, " "))
+}
+
+// Equal - dicts are equal if they have equal entries
+func (d Dict) Equal(other runtime.Data) bool {
+	o, ok := other.(Dict)
+	return ok && compareObject(makeTypedData(d), makeTypedData(o)) == 0
+}
+
+// Size - a key, a value and a tree node per entry
+func (d Dict) Size() int64 {
+	return 32 + 48*int64(d.Len())
+}
+
+func (d Dict) TypeName() string {
+	return "dict"
+}
@@ -12,6 +12,10 @@ import (
 type Name = runtime.Name
 
 type Extension = runtime.Extension
+type HigherOrderExtension = runtime.HigherOrderExtension
+type Caller = runtime.Caller
+type ParallelExtension = runtime.ParallelExtension
+type Parallel = runtime.Parallel
 
 func makeArithExtension(name string, f func(...Int) (Int, error)) Extension {
 	return Extension{
@@ -21,7 +25,7 @@ func makeArithExtension(name string, f func(...Int) (Int, error)) Extension {
 			for i, val := range values {
 				v, ok := val.Data().(Int)
 				if !ok {
-					return resultErrStrf(fmt.Sprintf("%s argument must be an integer", name))
+					return resultErrStrf("%s argument must be an integer", name)
 				}
 				vs[i] = v
 			}
@@ -139,3 +143,35 @@ var modExtension = makeArithExtension("mod", func(vs ...Int) (Int, error) {
 	}
 	return output, nil
 })
+
+var negExtension = makeArithExtension("neg", func(vs ...Int) (Int, error) {
+	if len(vs) != 1 {
+		return Int{}, errors.New("neg requires 1 argument")
+	}
+	return Int{-vs[0].Val}, nil
+})
+
+var andExtension = makeArithExtension("and", func(vs ...Int) (Int, error) {
+	for _, v := range vs {
+		if v.Val == 0 {
+			return False, nil
+		}
+	}
+	return True, nil
+})
+
+var orExtension = makeArithExtension("or", func(vs ...Int) (Int, error) {
+	for _, v := range vs {
+		if v.Val != 0 {
+			return True, nil
+		}
+	}
+	return False, nil
+})
+
+var notExtension = makeArithExtension("not", func(vs ...Int) (Int, error) {
+	if len(vs) != 1 {
+		return False, errors.New("not requires 1 argument")
+	}
+	return boolToBool(vs[0].Val == 0), nil
+})
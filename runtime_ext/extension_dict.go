@@ -0,0 +1,233 @@
+package runtime_ext
+
+import (
+	"context"
+	"el/runtime"
+	"fmt"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+func makeDict(values ...Object) (Dict, error) {
+	if len(values)%2 != 0 {
+		return Dict{}, fmt.Errorf("dict requires an even number of arguments")
+	}
+	d := Dict{}
+	for i := 0; i < len(values); i += 2 {
+		if err := checkOrdered(values[i]); err != nil {
+			return Dict{}, fmt.Errorf("dict key: %w", err)
+		}
+		d = d.Set(values[i], values[i+1])
+	}
+	return d, nil
+}
+
+var dictExtension = Extension{
+	Name: "dict",
+	Man:  "{builtin: (dict \"a\" 1 \"b\" 2) or @[\"a\" 1 \"b\" 2] - make a dict from keys and values}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if err := chargeDict(ctx, len(values)/2); err != nil {
+			return resultErr(err)
+		}
+		d, err := makeDict(values...)
+		if err != nil {
+			return resultErr(err)
+		}
+		return resultTypedData(d)
+	},
+}
+
+var getExtension = Extension{
+	Name: "get",
+	Man:  "{builtin: (get d k default) - get the value of key k in dict d or the element k of a list (negative from the end), default if missing}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 2 && len(values) != 3 {
+			return resultErrStrf("get requires 2 or 3 arguments")
+		}
+		var v Object
+		var ok bool
+		switch data := values[0].Data().(type) {
+		case Dict:
+			v, ok = data.Get(values[1])
+		case List:
+			i, err := intArg("get", values[1])
+			if err != nil {
+				return resultErr(err)
+			}
+			i, err = listIndex("get", data, i, false)
+			if err != nil && len(values) == 2 {
+				return resultErr(err)
+			}
+			if ok = err == nil; ok {
+				v = data.Get(i)
+			}
+		default:
+			return resultErrStrf("get first argument must be a dict or a list")
+		}
+		if ok {
+			return resultObj(v)
+		}
+		if len(values) == 3 {
+			return resultObj(values[2])
+		}
+		return resultErrStrf("get: %s not found", values[1])
+	},
+}
+
+func dictArg(name string, o Object) (Dict, error) {
+	d, ok := o.Data().(Dict)
+	if !ok {
+		return Dict{}, fmt.Errorf("%s first argument must be a dict", name)
+	}
+	return d, nil
+}
+
+var setExtension = Extension{
+	Name: "set",
+	Man:  "{builtin: (set d k v) - a copy of dict d with key k set to v, or of list d with element k set to v}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 3 {
+			return resultErrStrf("set requires 3 arguments")
+		}
+		switch data := values[0].Data().(type) {
+		case Dict:
+			if err := checkOrdered(values[1]); err != nil {
+				return resultErrStrf("set key: %w", err)
+			}
+			if err := chargeDict(ctx, 1); err != nil {
+				return resultErr(err)
+			}
+			return resultTypedData(data.Set(values[1], values[2]))
+		case List:
+			i, err := intArg("set", values[1])
+			if err != nil {
+				return resultErr(err)
+			}
+			if i, err = listIndex("set", data, i, false); err != nil {
+				return resultErr(err)
+			}
+			if err := chargeList(ctx, 0); err != nil {
+				return resultErr(err)
+			}
+			return resultTypedData(List{data.Set(i, values[2])})
+		default:
+			return resultErrStrf("set first argument must be a dict or a list")
+		}
+	},
+}
+
+var delExtension = Extension{
+	Name: "del",
+	Man:  "{builtin: (del d k) - a copy of dict d without key k, or of set d without element k}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 2 {
+			return resultErrStrf("del requires 2 arguments")
+		}
+		switch data := values[0].Data().(type) {
+		case Dict:
+			if err := chargeDict(ctx, 0); err != nil {
+				return resultErr(err)
+			}
+			return resultTypedData(data.Del(values[1]))
+		case Set:
+			if err := chargeSet(ctx, 0); err != nil {
+				return resultErr(err)
+			}
+			return resultTypedData(data.Del(values[1]))
+		default:
+			return resultErrStrf("del first argument must be a dict or a set")
+		}
+	},
+}
+
+var hasExtension = Extension{
+	Name: "has",
+	Man:  "{builtin: (has d k) - whether dict d has key k, or set d has element k}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 2 {
+			return resultErrStrf("has requires 2 arguments")
+		}
+		switch data := values[0].Data().(type) {
+		case Dict:
+			_, ok := data.Get(values[1])
+			return resultTypedData(boolToBool(ok))
+		case Set:
+			return resultTypedData(boolToBool(data.Has(values[1])))
+		default:
+			return resultErrStrf("has first argument must be a dict or a set")
+		}
+	},
+}
+
+// makeDictListExtension - a list with an element for every entry of a dict, entrySize is what f makes for an entry
+func makeDictListExtension(name string, man string, entrySize int64, f func(k Object, v Object) Object) Extension {
+	return Extension{
+		Name: Name(name),
+		Man:  man,
+		Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+			if len(values) != 1 {
+				return resultErrStrf("%s requires 1 argument", name)
+			}
+			d, err := dictArg(name, values[0])
+			if err != nil {
+				return resultErr(err)
+			}
+			if err := chargeList(ctx, d.Len()); err != nil {
+				return resultErr(err)
+			}
+			if err := runtime.Charge(ctx, entrySize*int64(d.Len())); err != nil {
+				return resultErr(err)
+			}
+			l := List{}
+			for k, v := range d.Iter {
+				l = List{l.PushBack(f(k, v))}
+			}
+			return resultTypedData(l)
+		},
+	}
+}
+
+var keysExtension = makeDictListExtension("keys", "{builtin: (keys d) - the keys of dict d in order}", 0,
+	func(k Object, v Object) Object { return k },
+)
+
+var valuesExtension = makeDictListExtension("values", "{builtin: (values d) - the values of dict d in key order}", 0,
+	func(k Object, v Object) Object { return v },
+)
+
+var itemsExtension = makeDictListExtension("items", "{builtin: (items d) - the [key value] pairs of dict d in key order}", List{}.Size()+2*16,
+	func(k Object, v Object) Object { return makeTypedData(List{List{}.PushBack(k, v)}) },
+)
+
+var mergeExtension = Extension{
+	Name: "merge",
+	Man:  "{builtin: (merge d1 d2 ...) - a dict with the entries of all dicts, later dicts win}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		n := 0
+		for i, value := range values {
+			d, ok := value.Data().(Dict)
+			if !ok {
+				return resultErrStrf("merge arguments must be dicts")
+			}
+			if i > 0 {
+				n += d.Len()
+			}
+		}
+		// the entries of the first dict are shared
+		if err := chargeDict(ctx, n); err != nil {
+			return resultErr(err)
+		}
+		output := Dict{}
+		for _, value := range values {
+			d := value.Data().(Dict)
+			if output.Len() == 0 {
+				output = d
+				continue
+			}
+			for k, v := range d.Iter {
+				output = output.Set(k, v)
+			}
+		}
+		return resultTypedData(output)
+	},
+}
@@ -0,0 +1,296 @@
+package runtime_ext
+
+import (
+	"context"
+	"el/runtime"
+	"fmt"
+	"iter"
+	"sort"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+// listFuncArgs - the (l f) arguments of a higher order builtin, l is a list or a seq
+func listFuncArgs(ctx context.Context, name string, values []Object) (iter.Seq2[Object, error], Object, error) {
+	if len(values) != 2 {
+		return nil, nil, fmt.Errorf("%s requires 2 arguments", name)
+	}
+	xs, err := elements(ctx, name, values[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return xs, values[1], nil
+}
+
+// callPredicate - call f and read its result as a boolean, any non-zero int is true
+func callPredicate(ctx context.Context, call Caller, name string, f Object, args ...Object) (bool, error) {
+	var o Object
+	if err := call(ctx, f, args...).Unwrap(&o); err != nil {
+		return false, err
+	}
+	if o == nil {
+		return false, fmt.Errorf("%s predicate must return a boolean", name)
+	}
+	i, ok := o.Data().(Int)
+	if !ok {
+		return false, fmt.Errorf("%s predicate must return a boolean, got %s", name, o)
+	}
+	return i.Val != 0, nil
+}
+
+var mapExtension = HigherOrderExtension{
+	Name: "map",
+	Man:  "[builtin: (map l f) - the list of f applied to every element of l]",
+	Exec: func(ctx context.Context, call Caller, values ...Object) adt.Result[Object] {
+		xs, f, err := listFuncArgs(ctx, "map", values)
+		if err != nil {
+			return resultErr(err)
+		}
+		if err := chargeList(ctx, 0); err != nil {
+			return resultErr(err)
+		}
+		output := List{}
+		for x, err := range xs {
+			if err != nil {
+				return resultErr(err)
+			}
+			var y Object
+			if err := call(ctx, f, x).Unwrap(&y); err != nil {
+				return resultErr(err)
+			}
+			if err := chargeListElems(ctx, 1); err != nil {
+				return resultErr(err)
+			}
+			output = List{output.PushBack(y)}
+		}
+		return resultTypedData(output)
+	},
+}
+
+var pmapExtension = ParallelExtension{
+	Name: "pmap",
+	Man:  "[builtin: (pmap l f) - map with the calls of f running in parallel, the results are in the order of l]",
+	Exec: func(ctx context.Context, call Caller, parallel Parallel, values ...Object) adt.Result[Object] {
+		if len(values) != 2 {
+			return resultErrStrf("pmap requires 2 arguments")
+		}
+		l, err := listArg(ctx, "pmap", values[0])
+		if err != nil {
+			return resultErr(err)
+		}
+		xs, f := l.Repr(), values[1]
+		if err := chargeList(ctx, len(xs)); err != nil {
+			return resultErr(err)
+		}
+		ys := make([]Object, len(xs))
+		err = parallel(ctx, len(xs), func(ctx context.Context, i int) error {
+			return call(ctx, f, xs[i]).Unwrap(&ys[i])
+		})
+		if err != nil {
+			return resultErr(err)
+		}
+		return resultTypedData(List{List{}.PushBack(ys...)})
+	},
+}
+
+var filterExtension = HigherOrderExtension{
+	Name: "filter",
+	Man:  "[builtin: (filter l pred) - the elements of l for which pred is true]",
+	Exec: func(ctx context.Context, call Caller, values ...Object) adt.Result[Object] {
+		xs, pred, err := listFuncArgs(ctx, "filter", values)
+		if err != nil {
+			return resultErr(err)
+		}
+		if err := chargeList(ctx, 0); err != nil {
+			return resultErr(err)
+		}
+		output := List{}
+		for x, err := range xs {
+			if err != nil {
+				return resultErr(err)
+			}
+			ok, err := callPredicate(ctx, call, "filter", pred, x)
+			if err != nil {
+				return resultErr(err)
+			}
+			if ok {
+				if err := chargeListElems(ctx, 1); err != nil {
+					return resultErr(err)
+				}
+				output = List{output.PushBack(x)}
+			}
+		}
+		return resultTypedData(output)
+	},
+}
+
+// makeFoldExtension - fold_left calls (f acc x) from the first element, fold_right calls (f x acc) from the last
+func makeFoldExtension(name string, man string, left bool) HigherOrderExtension {
+	return HigherOrderExtension{
+		Name: Name(name),
+		Man:  man,
+		Exec: func(ctx context.Context, call Caller, values ...Object) adt.Result[Object] {
+			if len(values) != 3 {
+				return resultErrStrf("%s requires 3 arguments", name)
+			}
+			acc, f := values[1], values[2]
+			if left {
+				xs, err := elements(ctx, name, values[0])
+				if err != nil {
+					return resultErr(err)
+				}
+				for x, err := range xs {
+					if err != nil {
+						return resultErr(err)
+					}
+					if err := call(ctx, f, acc, x).Unwrap(&acc); err != nil {
+						return resultErr(err)
+					}
+				}
+				return resultObj(acc)
+			}
+			l, err := listArg(ctx, name, values[0])
+			if err != nil {
+				return resultErr(err)
+			}
+			xs := l.Repr()
+			for i := len(xs) - 1; i >= 0; i-- {
+				if err := call(ctx, f, xs[i], acc).Unwrap(&acc); err != nil {
+					return resultErr(err)
+				}
+			}
+			return resultObj(acc)
+		},
+	}
+}
+
+var foldLeftExtension = makeFoldExtension("fold_left", "[builtin: (fold_left l init f) - (f (f init l[0]) l[1]) ...]", true)
+
+var foldRightExtension = makeFoldExtension("fold_right", "[builtin: (fold_right l init f) - (f l[0] (f l[1] init)) ...]", false)
+
+// makeQuantifierExtension - any stops at the first element for which pred is true, all at the first for which it is false
+func makeQuantifierExtension(name string, man string, want bool) HigherOrderExtension {
+	return HigherOrderExtension{
+		Name: Name(name),
+		Man:  man,
+		Exec: func(ctx context.Context, call Caller, values ...Object) adt.Result[Object] {
+			xs, pred, err := listFuncArgs(ctx, name, values)
+			if err != nil {
+				return resultErr(err)
+			}
+			for x, err := range xs {
+				if err != nil {
+					return resultErr(err)
+				}
+				ok, err := callPredicate(ctx, call, name, pred, x)
+				if err != nil {
+					return resultErr(err)
+				}
+				if ok == want {
+					return resultTypedData(boolToBool(want))
+				}
+			}
+			return resultTypedData(boolToBool(!want))
+		},
+	}
+}
+
+var anyExtension = makeQuantifierExtension("any", "[builtin: (any l pred) - whether pred is true for some element of l]", true)
+
+var allExtension = makeQuantifierExtension("all", "[builtin: (all l pred) - whether pred is true for every element of l]", false)
+
+var findExtension = HigherOrderExtension{
+	Name: "find",
+	Man:  "[builtin: (find l pred) - the first element of l for which pred is true, nil if there is none]",
+	Exec: func(ctx context.Context, call Caller, values ...Object) adt.Result[Object] {
+		xs, pred, err := listFuncArgs(ctx, "find", values)
+		if err != nil {
+			return resultErr(err)
+		}
+		for x, err := range xs {
+			if err != nil {
+				return resultErr(err)
+			}
+			ok, err := callPredicate(ctx, call, "find", pred, x)
+			if err != nil {
+				return resultErr(err)
+			}
+			if ok {
+				return resultObj(x)
+			}
+		}
+		return resultObj(makeNil())
+	},
+}
+
+var sortByExtension = HigherOrderExtension{
+	Name: "sort_by",
+	Man:  "[builtin: (sort_by l less) - l sorted by (less a b), stable]",
+	Exec: func(ctx context.Context, call Caller, values ...Object) adt.Result[Object] {
+		if len(values) != 2 {
+			return resultErrStrf("sort_by requires 2 arguments")
+		}
+		l, err := listArg(ctx, "sort_by", values[0])
+		if err != nil {
+			return resultErr(err)
+		}
+		xs, less := l.Repr(), values[1]
+		if err := chargeList(ctx, len(xs)); err != nil {
+			return resultErr(err)
+		}
+		var lessErr error
+		sort.SliceStable(xs, func(i, j int) bool {
+			if lessErr != nil {
+				return false
+			}
+			ok, err := callPredicate(ctx, call, "sort_by", less, xs[i], xs[j])
+			if err != nil {
+				lessErr = err
+			}
+			return ok
+		})
+		if lessErr != nil {
+			return resultErr(lessErr)
+		}
+		return resultTypedData(List{List{}.PushBack(xs...)})
+	},
+}
+
+var groupByExtension = HigherOrderExtension{
+	Name: "group_by",
+	Man:  "[builtin: (group_by l key) - a dict from (key x) to the list of elements x with that key, in order]",
+	Exec: func(ctx context.Context, call Caller, values ...Object) adt.Result[Object] {
+		xs, key, err := listFuncArgs(ctx, "group_by", values)
+		if err != nil {
+			return resultErr(err)
+		}
+		if err := chargeDict(ctx, 0); err != nil {
+			return resultErr(err)
+		}
+		output := Dict{}
+		for x, err := range xs {
+			if err != nil {
+				return resultErr(err)
+			}
+			var k Object
+			if err := call(ctx, key, x).Unwrap(&k); err != nil {
+				return resultErr(err)
+			}
+			if err := checkOrdered(k); err != nil {
+				return resultErrStrf("group_by key: %w", err)
+			}
+			group := List{}
+			if g, ok := output.Get(k); ok {
+				group = g.Data().(List)
+			} else if err := runtime.Charge(ctx, 48+List{}.Size()); err != nil {
+				// a new entry and its group
+				return resultErr(err)
+			}
+			if err := chargeListElems(ctx, 1); err != nil {
+				return resultErr(err)
+			}
+			output = output.Set(k, makeTypedData(List{group.PushBack(x)}))
+		}
+		return resultTypedData(output)
+	},
+}
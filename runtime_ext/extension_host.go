@@ -0,0 +1,143 @@
+package runtime_ext
+
+import (
+	"context"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"time"
+
+	"el/runtime"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+// rootFS - the files under root, opened for every call so that the grant holds no descriptor
+func rootFS(root string, f func(fsys fs.FS) adt.Result[Object]) adt.Result[Object] {
+	r, err := os.OpenRoot(root)
+	if err != nil {
+		return resultErr(err)
+	}
+	defer r.Close()
+	return f(r.FS())
+}
+
+func makeReadFileExtension(root string) Extension {
+	return Extension{
+		Name: "read_file",
+		Man:  "[builtin: (read_file path) - the content of the file at path under the granted root]",
+		Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+			path, err := stringArgs("read_file", 1, values)
+			if err != nil {
+				return resultErr(err)
+			}
+			return rootFS(root, func(fsys fs.FS) adt.Result[Object] {
+				b, err := fs.ReadFile(fsys, path[0])
+				if err != nil {
+					return resultErr(err)
+				}
+				return resultNew(ctx, String{Val: string(b)})
+			})
+		},
+	}
+}
+
+func makeListDirExtension(root string) Extension {
+	return Extension{
+		Name: "list_dir",
+		Man:  "[builtin: (list_dir path) - the sorted names in the directory at path under the granted root, \".\" is the root]",
+		Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+			path, err := stringArgs("list_dir", 1, values)
+			if err != nil {
+				return resultErr(err)
+			}
+			return rootFS(root, func(fsys fs.FS) adt.Result[Object] {
+				entries, err := fs.ReadDir(fsys, path[0])
+				if err != nil {
+					return resultErr(err)
+				}
+				if err := chargeList(ctx, len(entries)); err != nil {
+					return resultErr(err)
+				}
+				output := List{}
+				for _, entry := range entries {
+					if err := chargeString(ctx, len(entry.Name())); err != nil {
+						return resultErr(err)
+					}
+					output = List{output.PushBack(makeTypedData(String{Val: entry.Name()}))}
+				}
+				return resultTypedData(output)
+			})
+		},
+	}
+}
+
+var nowExtension = Extension{
+	Name: "now",
+	Man:  "[builtin: (now) - milliseconds since the unix epoch]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 0 {
+			return resultErrStrf("now takes no arguments")
+		}
+		return resultTypedData(Int{Val: int(time.Now().UnixMilli())})
+	},
+}
+
+var sleepExtension = Extension{
+	Name: "sleep",
+	Man:  "[builtin: (sleep ms) - wait for ms milliseconds or until the script is interrupted]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 1 {
+			return resultErrStrf("sleep requires 1 argument")
+		}
+		ms, ok := values[0].Data().(Int)
+		if !ok || ms.Val < 0 {
+			return resultErrStrf("sleep duration must be a non-negative integer")
+		}
+		timer := time.NewTimer(time.Duration(ms.Val) * time.Millisecond)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return resultObj(makeNil())
+		case <-ctx.Done():
+			return resultErr(runtime.CheckInterrupt(ctx))
+		}
+	},
+}
+
+var randIntExtension = Extension{
+	Name: "rand_int",
+	Man:  "[builtin: (rand_int n) - a random integer in [0, n)]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 1 {
+			return resultErrStrf("rand_int requires 1 argument")
+		}
+		n, ok := values[0].Data().(Int)
+		if !ok || n.Val <= 0 {
+			return resultErrStrf("rand_int bound must be a positive integer")
+		}
+		return resultTypedData(Int{Val: rand.IntN(n.Val)})
+	},
+}
+
+func makeGetenvExtension(names []string) Extension {
+	return Extension{
+		Name: "getenv",
+		Man:  "[builtin: (getenv name) - the value of a granted environment variable, nil if it is not set]",
+		Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+			name, err := stringArgs("getenv", 1, values)
+			if err != nil {
+				return resultErr(err)
+			}
+			if !slices.Contains(names, name[0]) {
+				return resultErrStrf("getenv: %s is not granted", name[0])
+			}
+			val, ok := os.LookupEnv(name[0])
+			if !ok {
+				return resultObj(makeNil())
+			}
+			return resultNew(ctx, String{Val: val})
+		},
+	}
+}
@@ -0,0 +1,212 @@
+package runtime_ext
+
+import (
+	"bytes"
+	"context"
+	"el/runtime"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+// fromJSON - the el value of a decoded JSON value,
+// numbers are ints when their value is an integer that fits an int, e.g. -3e2, else floats,
+// booleans are true and false, strings, arrays and objects are charged before they are made
+func fromJSON(ctx context.Context, v any) (Object, error) {
+	switch v := v.(type) {
+	case nil:
+		return makeNil(), nil
+	case bool:
+		return makeTypedData(boolToBool(v)), nil
+	case json.Number:
+		if i, err := strconv.Atoi(v.String()); err == nil {
+			return makeTypedData(Int{Val: i}), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		if f == math.Trunc(f) && f >= math.MinInt && f < math.MaxInt {
+			return makeTypedData(Int{Val: int(f)}), nil
+		}
+		return makeTypedData(Float{Val: f}), nil
+	case string:
+		if err := chargeString(ctx, len(v)); err != nil {
+			return nil, err
+		}
+		return makeTypedData(String{Val: v}), nil
+	case []any:
+		if err := chargeList(ctx, len(v)); err != nil {
+			return nil, err
+		}
+		l := List{}
+		for _, x := range v {
+			o, err := fromJSON(ctx, x)
+			if err != nil {
+				return nil, err
+			}
+			l = List{l.PushBack(o)}
+		}
+		return makeTypedData(l), nil
+	case map[string]any:
+		if err := chargeDict(ctx, len(v)); err != nil {
+			return nil, err
+		}
+		d := Dict{}
+		for k, x := range v {
+			if err := chargeString(ctx, len(k)); err != nil {
+				return nil, err
+			}
+			o, err := fromJSON(ctx, x)
+			if err != nil {
+				return nil, err
+			}
+			d = d.Set(makeTypedData(String{Val: k}), o)
+		}
+		return makeTypedData(d), nil
+	default:
+		return nil, fmt.Errorf("unexpected JSON value %v", v)
+	}
+}
+
+// writeJSON - write the JSON of an el value, the path of the value is in the errors,
+// dicts become objects and need string keys, sets become arrays in their order
+func writeJSON(b *bytes.Buffer, path string, o Object) error {
+	if o == nil {
+		b.WriteString("null")
+		return nil
+	}
+	switch data := o.Data().(type) {
+	case runtime.Nil:
+		b.WriteString("null")
+	case Int:
+		b.WriteString(strconv.Itoa(data.Val))
+	case Float:
+		s, err := json.Marshal(data.Val)
+		if err != nil {
+			return fmt.Errorf("cannot encode %s at %s", data, path)
+		}
+		b.Write(s)
+	case String:
+		s, _ := json.Marshal(data.Val)
+		b.Write(s)
+	case List:
+		b.WriteByte('[')
+		for i, x := range data.Iter {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeJSON(b, fmt.Sprintf("%s[%d]", path, i), x); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	case Set:
+		return writeJSON(b, path, makeTypedData(data.list()))
+	case Dict:
+		b.WriteByte('{')
+		i := 0
+		for k, x := range data.Iter {
+			key, ok := k.Data().(String)
+			if !ok {
+				return fmt.Errorf("key %s of type %s at %s is not a string", k, typeNameOf(k), path)
+			}
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			i++
+			s, _ := json.Marshal(key.Val)
+			b.Write(s)
+			b.WriteByte(':')
+			if err := writeJSON(b, fmt.Sprintf("%s[%s]", path, s), x); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	default:
+		return fmt.Errorf("cannot encode a value of type %s at %s", typeNameOf(o), path)
+	}
+	return nil
+}
+
+var jsonParseExtension = Extension{
+	Name: "json_parse",
+	Man:  "{builtin: (json_parse s) - the value of the JSON text s, objects become dicts, arrays lists, null nil and booleans true and false}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		ss, err := stringArgs("json_parse", 1, values)
+		if err != nil {
+			return resultErr(err)
+		}
+		dec := json.NewDecoder(strings.NewReader(ss[0]))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return resultErrStrf("json_parse: %v", err)
+		}
+		if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+			return resultErrStrf("json_parse: unexpected data after the value at offset %d", dec.InputOffset())
+		}
+		o, err := fromJSON(ctx, v)
+		if err != nil {
+			return resultErrStrf("json_parse: %v", err)
+		}
+		return resultObj(o)
+	},
+}
+
+var jsonStringifyExtension = Extension{
+	Name:     "json_stringify",
+	Man:      "{builtin: (json_stringify v [indent]) - the JSON text of v, pretty printed if indent (or :indent) is a number of spaces or a string}",
+	Keywords: true,
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		values, keywords := keywordDict(values)
+		if len(values) != 1 && len(values) != 2 {
+			return resultErrStrf("json_stringify requires 1 or 2 arguments")
+		}
+		var indentArg Object
+		if len(values) == 2 {
+			indentArg = values[1]
+		}
+		for k, v := range keywords.Iter {
+			if k.String() != "indent" {
+				return resultErrStrf("json_stringify: unknown keyword argument %s", k)
+			}
+			if indentArg != nil {
+				return resultErrStrf("json_stringify: indent is given by position and by keyword")
+			}
+			indentArg = v
+		}
+		indent := ""
+		if indentArg != nil {
+			switch data := indentArg.Data().(type) {
+			case Int:
+				if data.Val < 0 {
+					return resultErrStrf("json_stringify indent must not be negative")
+				}
+				indent = strings.Repeat(" ", data.Val)
+			case String:
+				indent = data.Val
+			default:
+				return resultErrStrf("json_stringify indent must be an integer or a string")
+			}
+		}
+		var b bytes.Buffer
+		if err := writeJSON(&b, "$", values[0]); err != nil {
+			return resultErrStrf("json_stringify: %v", err)
+		}
+		if len(indent) == 0 {
+			return resultNew(ctx, String{Val: b.String()})
+		}
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, b.Bytes(), "", indent); err != nil {
+			return resultErrStrf("json_stringify: %v", err)
+		}
+		return resultNew(ctx, String{Val: pretty.String()})
+	},
+}
@@ -0,0 +1,191 @@
+package runtime_ext
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+// lazySource - a list or a seq as a seq
+func lazySource(name string, o Object) (LazySeq, error) {
+	switch data := o.Data().(type) {
+	case LazySeq:
+		return data, nil
+	case List:
+		return makeLazySeq(data.String(), func(ctx context.Context, yield func(Object) bool) error {
+			for _, x := range data.Iter {
+				if !yield(x) {
+					return nil
+				}
+			}
+			return nil
+		}), nil
+	default:
+		return LazySeq{}, fmt.Errorf("%s argument must be a list or a seq", name)
+	}
+}
+
+var iterateExtension = HigherOrderExtension{
+	Name: "iterate",
+	Man:  "[builtin: (iterate f x) - the unbounded seq x, (f x), (f (f x)) ...]",
+	Exec: func(ctx context.Context, call Caller, values ...Object) adt.Result[Object] {
+		if len(values) != 2 {
+			return resultErrStrf("iterate requires 2 arguments")
+		}
+		f, x := values[0], values[1]
+		return resultTypedData(makeLazySeq(fmt.Sprintf("iterate %s", x), func(ctx context.Context, yield func(Object) bool) error {
+			x := x
+			for yield(x) {
+				if err := call(ctx, f, x).Unwrap(&x); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	},
+}
+
+var repeatExtension = Extension{
+	Name: "repeat",
+	Man:  "[builtin: (repeat x n) - the seq of n copies of x, unbounded without n]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 1 && len(values) != 2 {
+			return resultErrStrf("repeat requires 1 or 2 arguments")
+		}
+		x, n := values[0], -1
+		repr := fmt.Sprintf("repeat %s", x)
+		if len(values) == 2 {
+			count, ok := values[1].Data().(Int)
+			if !ok || count.Val < 0 {
+				return resultErrStrf("repeat count must be a non-negative integer")
+			}
+			n = count.Val
+			repr = fmt.Sprintf("repeat %s %d", x, n)
+		}
+		return resultTypedData(makeLazySeq(repr, func(ctx context.Context, yield func(Object) bool) error {
+			for i := 0; n < 0 || i < n; i++ {
+				if !yield(x) {
+					return nil
+				}
+			}
+			return nil
+		}))
+	},
+}
+
+// makeLazyFilterExtension - a seq of the elements of the source while or where pred is true
+func makeLazyFilterExtension(name string, man string, while bool) HigherOrderExtension {
+	return HigherOrderExtension{
+		Name: Name(name),
+		Man:  man,
+		Exec: func(ctx context.Context, call Caller, values ...Object) adt.Result[Object] {
+			if len(values) != 2 {
+				return resultErrStrf("%s requires 2 arguments", name)
+			}
+			src, err := lazySource(name, values[0])
+			if err != nil {
+				return resultErr(err)
+			}
+			pred := values[1]
+			return resultTypedData(makeLazySeq(fmt.Sprintf("%s %s", name, src), func(ctx context.Context, yield func(Object) bool) error {
+				for x, err := range src.all(ctx) {
+					if err != nil {
+						return err
+					}
+					ok, err := callPredicate(ctx, call, name, pred, x)
+					if err != nil {
+						return err
+					}
+					if !ok && while {
+						return nil
+					}
+					if ok && !yield(x) {
+						return nil
+					}
+				}
+				return nil
+			}))
+		},
+	}
+}
+
+var takeWhileExtension = makeLazyFilterExtension("take_while", "[builtin: (take_while l pred) - the seq of the elements of l up to the first for which pred is false]", true)
+
+var filterLazyExtension = makeLazyFilterExtension("filter_lazy", "[builtin: (filter_lazy l pred) - the seq of the elements of l for which pred is true]", false)
+
+var mapLazyExtension = HigherOrderExtension{
+	Name: "map_lazy",
+	Man:  "[builtin: (map_lazy l f) - the seq of f applied to every element of l]",
+	Exec: func(ctx context.Context, call Caller, values ...Object) adt.Result[Object] {
+		if len(values) != 2 {
+			return resultErrStrf("map_lazy requires 2 arguments")
+		}
+		src, err := lazySource("map_lazy", values[0])
+		if err != nil {
+			return resultErr(err)
+		}
+		f := values[1]
+		return resultTypedData(makeLazySeq(fmt.Sprintf("map_lazy %s", src), func(ctx context.Context, yield func(Object) bool) error {
+			for x, err := range src.all(ctx) {
+				if err != nil {
+					return err
+				}
+				var y Object
+				if err := call(ctx, f, x).Unwrap(&y); err != nil {
+					return err
+				}
+				if !yield(y) {
+					return nil
+				}
+			}
+			return nil
+		}))
+	},
+}
+
+var zipExtension = Extension{
+	Name: "zip",
+	Man:  "[builtin: (zip l1 l2 ...) - the seq of lists [l1[i] l2[i] ...], as long as the shortest argument]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) < 1 {
+			return resultErrStrf("zip requires at least 1 argument")
+		}
+		srcs := make([]LazySeq, 0, len(values))
+		reprs := make([]string, 0, len(values))
+		for _, v := range values {
+			src, err := lazySource("zip", v)
+			if err != nil {
+				return resultErr(err)
+			}
+			srcs = append(srcs, src)
+			reprs = append(reprs, src.String())
+		}
+		return resultTypedData(makeLazySeq("zip "+strings.Join(reprs, " "), func(ctx context.Context, yield func(Object) bool) error {
+			nexts := make([]func() (Object, error, bool), 0, len(srcs))
+			for _, src := range srcs {
+				next, stop := iter.Pull2(src.all(ctx))
+				defer stop()
+				nexts = append(nexts, next)
+			}
+			for {
+				tuple := make([]Object, 0, len(nexts))
+				for _, next := range nexts {
+					x, err, ok := next()
+					if err != nil {
+						return err
+					}
+					if !ok {
+						return nil
+					}
+					tuple = append(tuple, x)
+				}
+				if !yield(makeTypedData(List{List{}.PushBack(tuple...)})) {
+					return nil
+				}
+			}
+		}))
+	},
+}
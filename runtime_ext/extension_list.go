@@ -2,6 +2,9 @@ package runtime_ext
 
 import (
 	"context"
+	"el/runtime"
+	"fmt"
+	"unicode/utf8"
 
 	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
 )
@@ -10,6 +13,9 @@ var listExtension = Extension{
 	Name: "list",
 	Man:  "[builtin: (list 1 2 (lambda x (add x 1))) - make a list]",
 	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if err := chargeList(ctx, len(values)); err != nil {
+			return resultErr(err)
+		}
 		l := List{}
 		for _, v := range values {
 			l = List{l.Ins(l.Len(), v)}
@@ -20,41 +26,61 @@ var listExtension = Extension{
 
 var lenExtension = Extension{
 	Name: "len",
-	Man:  "[builtin: (len (list 1 2 3)) - get the length of a list]",
+	Man:  "[builtin: (len (list 1 2 3)) - get the length of a list, seq, dict or set, or the number of runes of a string]",
 	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
 		if len(values) != 1 {
 			return resultErrStrf("len requires 1 argument")
 		}
-		l, ok := values[0].Data().(List)
-		if !ok {
-			return resultErrStrf("len argument must be a list")
+		switch data := values[0].Data().(type) {
+		case List:
+			return resultTypedData(Int{data.Len()})
+		case LazySeq:
+			l, err := collect(ctx, data)
+			if err != nil {
+				return resultErr(err)
+			}
+			return resultTypedData(Int{l.Len()})
+		case String:
+			return resultTypedData(Int{utf8.RuneCountInString(data.Val)})
+		case Dict:
+			return resultTypedData(Int{data.Len()})
+		case Set:
+			return resultTypedData(Int{data.Len()})
+		default:
+			return resultErrStrf("len argument must be a list, seq, dict, set or string")
 		}
-		return resultTypedData(Int{l.Len()})
 	},
 }
 
 var sliceExtension = Extension{
 	Name: "slice",
-	Man:  "[builtin: (get (list 1 2 3) (list 0 2)) - get the 0th and 2nd element of a list]",
+	Man:  "[builtin: (slice (list 1 2 3) (list 0 2)) - get the 0th and 2nd element of a list]",
 	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
 		if len(values) != 2 {
 			return resultErrStrf("slice requires 2 arguments")
 		}
-		l, ok := values[0].Data().(List)
-		if !ok {
-			return resultErrStrf("slice first argument must be a list")
+		l, err := listArg(ctx, "slice", values[0])
+		if err != nil {
+			return resultErr(err)
 		}
-		i, ok := values[1].Data().(List)
-		if !ok {
+		i, err := listArg(ctx, "slice", values[1])
+		if err != nil {
 			return resultErrStrf("slice second argument must be a list of integers")
 		}
+		if err := chargeList(ctx, i.Len()); err != nil {
+			return resultErr(err)
+		}
 		output := List{}
 		for _, o := range i.Iter {
 			index, ok := o.Data().(Int)
 			if !ok {
 				return resultErrStrf("slice second argument must be a list of integers")
 			}
-			v := l.Get(index.Val)
+			i, err := listIndex("slice", l, index.Val, false)
+			if err != nil {
+				return resultErr(err)
+			}
+			v := l.Get(i)
 			output = List{output.Ins(output.Len(), v)}
 		}
 		return resultTypedData(output)
@@ -63,7 +89,7 @@ var sliceExtension = Extension{
 
 var rangeExtension = Extension{
 	Name: "range",
-	Man:  "[builtin: (range m n) - make a list of integers from m to n-1]",
+	Man:  "[builtin: (range m n) - a lazy seq of integers from m to n-1]",
 	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
 		if len(values) != 2 {
 			return resultErrStrf("range requires 2 arguments")
@@ -76,10 +102,257 @@ var rangeExtension = Extension{
 		if !ok {
 			return resultErrStrf("range end must be an integer")
 		}
+		repr := fmt.Sprintf("range %d %d", i.Val, j.Val)
+		return resultTypedData(makeLazySeq(repr, func(ctx context.Context, yield func(Object) bool) error {
+			for k := i.Val; k < j.Val; k++ {
+				if !yield(makeTypedData(Int{k})) {
+					return nil
+				}
+			}
+			return nil
+		}))
+	},
+}
+
+// listIndex - resolve a possibly negative index, -1 is the last element
+// end allows the index one past the last element, as a position to insert or split at
+func listIndex(name string, l List, i int, end bool) (int, error) {
+	n := l.Len()
+	j := i
+	if j < 0 {
+		j += n
+		if end {
+			j++
+		}
+	}
+	if j < 0 || j > n || (j == n && !end) {
+		return 0, fmt.Errorf("%s index %d out of range for list of length %d", name, i, n)
+	}
+	return j, nil
+}
+
+// listArg - a list argument, a lazy sequence is materialized
+func listArg(ctx context.Context, name string, o Object) (List, error) {
+	switch data := o.Data().(type) {
+	case List:
+		return data, nil
+	case LazySeq:
+		return collect(ctx, data)
+	default:
+		return List{}, fmt.Errorf("%s argument must be a list", name)
+	}
+}
+
+func intArg(name string, o Object) (int, error) {
+	i, ok := o.Data().(Int)
+	if !ok {
+		return 0, fmt.Errorf("%s index must be an integer", name)
+	}
+	return i.Val, nil
+}
+
+var deleteExtension = Extension{
+	Name: "delete",
+	Man:  "[builtin: (delete l i) - a copy of list l without the element at index i]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 2 {
+			return resultErrStrf("delete requires 2 arguments")
+		}
+		l, err := listArg(ctx, "delete", values[0])
+		if err != nil {
+			return resultErr(err)
+		}
+		i, err := intArg("delete", values[1])
+		if err != nil {
+			return resultErr(err)
+		}
+		if i, err = listIndex("delete", l, i, false); err != nil {
+			return resultErr(err)
+		}
+		if err := chargeList(ctx, 0); err != nil {
+			return resultErr(err)
+		}
+		return resultTypedData(List{l.Del(i)})
+	},
+}
+
+var concatExtension = Extension{
+	Name: "concat",
+	Man:  "[builtin: (concat [1 2] [3] [4 5]) - join lists, or strings if the first argument is a string]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) > 0 {
+			if _, ok := values[0].Data().(String); ok {
+				return concatStrings(ctx, values)
+			}
+		}
+		lists := make([]List, 0, len(values))
+		n := 0
+		for _, v := range values {
+			l, err := listArg(ctx, "concat", v)
+			if err != nil {
+				return resultErr(err)
+			}
+			lists = append(lists, l)
+			n += l.Len()
+		}
+		if err := chargeList(ctx, n); err != nil {
+			return resultErr(err)
+		}
+		output := List{}
+		for _, l := range lists {
+			output = List{output.Merge(l.Seq)}
+		}
+		return resultTypedData(output)
+	},
+}
+
+var splitAtExtension = Extension{
+	Name: "split_at",
+	Man:  "[builtin: (split_at l i) - the lists [l[:i] l[i:]], 0 <= i <= (len l)]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 2 {
+			return resultErrStrf("split_at requires 2 arguments")
+		}
+		l, err := listArg(ctx, "split_at", values[0])
+		if err != nil {
+			return resultErr(err)
+		}
+		i, ok := values[1].Data().(Int)
+		if !ok || i.Val < 0 {
+			return resultErrStrf("split_at index must be a non-negative integer")
+		}
+		if _, err = listIndex("split_at", l, i.Val, true); err != nil {
+			return resultErr(err)
+		}
+		// the pair and the headers of both halves, the halves share the elements of l
+		if err := chargeList(ctx, 2); err != nil {
+			return resultErr(err)
+		}
+		if err := runtime.Charge(ctx, 2*List{}.Size()); err != nil {
+			return resultErr(err)
+		}
+		left, right := l.Split(i.Val)
+		return resultTypedData(List{List{}.PushBack(makeTypedData(List{left}), makeTypedData(List{right}))})
+	},
+}
+
+var reverseExtension = Extension{
+	Name: "reverse",
+	Man:  "[builtin: (reverse l) - the elements of list l in reverse order]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 1 {
+			return resultErrStrf("reverse requires 1 argument")
+		}
+		l, err := listArg(ctx, "reverse", values[0])
+		if err != nil {
+			return resultErr(err)
+		}
+		if err := chargeList(ctx, l.Len()); err != nil {
+			return resultErr(err)
+		}
 		output := List{}
-		for k := i.Val; k < j.Val; k++ {
-			output = List{output.Ins(output.Len(), makeTypedData(Int{k}))}
+		for _, o := range l.Iter {
+			output = List{output.PushFront(o)}
 		}
 		return resultTypedData(output)
 	},
 }
+
+var pushExtension = Extension{
+	Name: "push",
+	Man:  "[builtin: (push l 4 5) - a copy of list l with values appended]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) < 1 {
+			return resultErrStrf("push requires at least 1 argument")
+		}
+		l, err := listArg(ctx, "push", values[0])
+		if err != nil {
+			return resultErr(err)
+		}
+		if err := chargeList(ctx, len(values)-1); err != nil {
+			return resultErr(err)
+		}
+		return resultTypedData(List{l.PushBack(values[1:]...)})
+	},
+}
+
+var popExtension = Extension{
+	Name: "pop",
+	Man:  "[builtin: (pop l) - a copy of list l without its last element]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 1 {
+			return resultErrStrf("pop requires 1 argument")
+		}
+		l, err := listArg(ctx, "pop", values[0])
+		if err != nil {
+			return resultErr(err)
+		}
+		if l.Len() == 0 {
+			return resultErrStrf("pop from empty list")
+		}
+		if err := chargeList(ctx, 0); err != nil {
+			return resultErr(err)
+		}
+		return resultTypedData(List{l.PopBack()})
+	},
+}
+
+// makeTakeDropExtension - take and drop take the list first, as map, clamp n to the length of the list, on a seq they are lazy
+func makeTakeDropExtension(name string, man string, take bool) Extension {
+	return Extension{
+		Name: Name(name),
+		Man:  man,
+		Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+			if len(values) != 2 {
+				return resultErrStrf("%s requires 2 arguments", name)
+			}
+			n, ok := values[1].Data().(Int)
+			if !ok || n.Val < 0 {
+				return resultErrStrf("%s count must be a non-negative integer", name)
+			}
+			if src, ok := values[0].Data().(LazySeq); ok {
+				return resultTypedData(takeDropLazy(name, n.Val, src, take))
+			}
+			l, err := listArg(ctx, name, values[0])
+			if err != nil {
+				return resultErr(err)
+			}
+			if err := chargeList(ctx, 0); err != nil {
+				return resultErr(err)
+			}
+			left, right := l.Split(min(n.Val, l.Len()))
+			if take {
+				return resultTypedData(List{left})
+			}
+			return resultTypedData(List{right})
+		},
+	}
+}
+
+var takeExtension = makeTakeDropExtension("take", "[builtin: (take l n) - the first n elements of list l]", true)
+
+var dropExtension = makeTakeDropExtension("drop", "[builtin: (drop l n) - list l without its first n elements]", false)
+
+func takeDropLazy(name string, n int, src LazySeq, take bool) LazySeq {
+	repr := fmt.Sprintf("%s %s %d", name, src, n)
+	return makeLazySeq(repr, func(ctx context.Context, yield func(Object) bool) error {
+		if take && n == 0 {
+			return nil
+		}
+		i := 0
+		for x, err := range src.all(ctx) {
+			if err != nil {
+				return err
+			}
+			if (take || i >= n) && !yield(x) {
+				return nil
+			}
+			i++
+			if take && i >= n {
+				// src is not pulled past the last element taken, it may never have another
+				return nil
+			}
+		}
+		return nil
+	})
+}
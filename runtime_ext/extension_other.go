@@ -2,23 +2,39 @@ package runtime_ext
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
 )
 
+func fprintValues(w io.Writer, values ...Object) {
+	for i, v := range values {
+		// fmt.Printf("{%s : %s}", v, v.Type())
+		fmt.Fprint(w, v)
+		if i < len(values)-1 {
+			fmt.Fprint(w, " ")
+		}
+	}
+	fmt.Fprintln(w)
+}
+
 var printExtension = Extension{
 	Name: "print",
 	Man:  "{builtin: (print 1 2 (lambda x (add x 1))) - print}",
 	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
-		for i, v := range values {
-			// fmt.Printf("{%s : %s}", v, v.Type())
-			fmt.Print(v)
-			if i < len(values)-1 {
-				fmt.Print(" ")
-			}
-		}
-		fmt.Println()
+		fprintValues(IOFrom(ctx).Stdout, values...)
+		return resultObj(nil)
+	},
+}
+
+var eprintExtension = Extension{
+	Name: "eprint",
+	Man:  "{builtin: (eprint \"warning\" 1) - print to stderr}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		fprintValues(IOFrom(ctx).Stderr, values...)
 		return resultObj(nil)
 	},
 }
@@ -30,16 +46,52 @@ var inspectExtension = Extension{
 		if len(values) < 1 {
 			return resultErrStrf("inspect requires a welcoming message")
 		}
+		w := IOFrom(ctx).Stdout
 		msgObj := values[0]
-		fmt.Print(msgObj)
+		fmt.Fprint(w, msgObj)
 		for i := 1; i < len(values); i++ {
 			v := values[i]
-			fmt.Printf("{%s : %s}", v, v.Type())
+			fmt.Fprintf(w, "{%s : %s}", v, v.Type())
 			if i < len(values)-1 {
-				fmt.Print(" ")
+				fmt.Fprint(w, " ")
 			}
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 		return resultObj(nil)
 	},
 }
+
+var readLineExtension = Extension{
+	Name: "read_line",
+	Man:  "{builtin: (read_line) - read a line from stdin without the line break, nil at the end of input}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 0 {
+			return resultErrStrf("read_line takes no arguments")
+		}
+		line, err := IOFrom(ctx).Stdin.ReadString('\n')
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			return resultObj(makeNil())
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return resultErr(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		line = strings.TrimSuffix(line, "\r")
+		return resultNew(ctx, String{Val: line})
+	},
+}
+
+var readAllExtension = Extension{
+	Name: "read_all",
+	Man:  "{builtin: (read_all) - read the rest of stdin}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 0 {
+			return resultErrStrf("read_all takes no arguments")
+		}
+		b, err := IOFrom(ctx).Stdin.ReadAll()
+		if err != nil {
+			return resultErr(err)
+		}
+		return resultNew(ctx, String{Val: string(b)})
+	},
+}
@@ -0,0 +1,181 @@
+package runtime_ext
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+func makeSet(values ...Object) (Set, error) {
+	s := Set{}
+	for _, v := range values {
+		if err := checkOrdered(v); err != nil {
+			return Set{}, fmt.Errorf("set element: %w", err)
+		}
+		s = s.Ins(v)
+	}
+	return s, nil
+}
+
+var setOfExtension = Extension{
+	Name: "set_of",
+	Man:  "{builtin: (set_of 1 2 3) - make a set}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if err := chargeSet(ctx, len(values)); err != nil {
+			return resultErr(err)
+		}
+		s, err := makeSet(values...)
+		if err != nil {
+			return resultErr(err)
+		}
+		return resultTypedData(s)
+	},
+}
+
+var toSetExtension = Extension{
+	Name: "to_set",
+	Man:  "{builtin: (to_set [1 2 2 3]) - make a set from the elements of a list}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 1 {
+			return resultErrStrf("to_set requires 1 argument")
+		}
+		l, err := listArg(ctx, "to_set", values[0])
+		if err != nil {
+			return resultErr(err)
+		}
+		if err := chargeSet(ctx, l.Len()); err != nil {
+			return resultErr(err)
+		}
+		s, err := makeSet(l.Repr()...)
+		if err != nil {
+			return resultErr(err)
+		}
+		return resultTypedData(s)
+	},
+}
+
+var toListExtension = Extension{
+	Name: "to_list",
+	Man:  "{builtin: (to_list s) - the elements of a set in order, or of a seq}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 1 {
+			return resultErrStrf("to_list requires 1 argument")
+		}
+		switch data := values[0].Data().(type) {
+		case Set:
+			if err := chargeList(ctx, data.Len()); err != nil {
+				return resultErr(err)
+			}
+			return resultTypedData(data.list())
+		case LazySeq, List:
+			l, err := listArg(ctx, "to_list", values[0])
+			if err != nil {
+				return resultErr(err)
+			}
+			return resultTypedData(l)
+		default:
+			return resultErrStrf("to_list argument must be a set, a seq or a list")
+		}
+	},
+}
+
+var insertExtension = Extension{
+	Name: "insert",
+	Man:  "{builtin: (insert s x) - a copy of set s with x, (insert l i x) - a copy of list l with x at index i}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) < 1 {
+			return resultErrStrf("insert requires at least 1 argument")
+		}
+		switch data := values[0].Data().(type) {
+		case Set:
+			if len(values) != 2 {
+				return resultErrStrf("insert into a set requires 2 arguments")
+			}
+			if err := checkOrdered(values[1]); err != nil {
+				return resultErrStrf("insert element: %w", err)
+			}
+			if err := chargeSet(ctx, 1); err != nil {
+				return resultErr(err)
+			}
+			return resultTypedData(data.Ins(values[1]))
+		case List:
+			if len(values) != 3 {
+				return resultErrStrf("insert into a list requires 3 arguments")
+			}
+			i, err := intArg("insert", values[1])
+			if err != nil {
+				return resultErr(err)
+			}
+			if i, err = listIndex("insert", data, i, true); err != nil {
+				return resultErr(err)
+			}
+			if err := chargeList(ctx, 1); err != nil {
+				return resultErr(err)
+			}
+			return resultTypedData(List{data.Ins(i, values[2])})
+		default:
+			return resultErrStrf("insert first argument must be a set or a list")
+		}
+	},
+}
+
+func setArgs(name string, values []Object) ([]Set, error) {
+	sets := make([]Set, 0, len(values))
+	for _, v := range values {
+		s, ok := v.Data().(Set)
+		if !ok {
+			return nil, fmt.Errorf("%s arguments must be sets", name)
+		}
+		sets = append(sets, s)
+	}
+	return sets, nil
+}
+
+func makeSetAlgebraExtension(name string, man string, f func(Set, Set) Set) Extension {
+	return Extension{
+		Name: Name(name),
+		Man:  man,
+		Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+			if len(values) < 1 {
+				return resultErrStrf("%s requires at least 1 argument", name)
+			}
+			sets, err := setArgs(name, values)
+			if err != nil {
+				return resultErr(err)
+			}
+			output := sets[0]
+			for _, s := range sets[1:] {
+				output = f(output, s)
+			}
+			// built before it is charged, it is no larger than the sets it is made from, which are charged
+			if len(sets) > 1 {
+				if err := chargeSet(ctx, output.Len()); err != nil {
+					return resultErr(err)
+				}
+			}
+			return resultTypedData(output)
+		},
+	}
+}
+
+var unionExtension = makeSetAlgebraExtension("union", "{builtin: (union s1 s2 ...) - elements in any set}", Set.Union)
+
+var intersectExtension = makeSetAlgebraExtension("intersect", "{builtin: (intersect s1 s2 ...) - elements in every set}", Set.Intersect)
+
+var differenceExtension = makeSetAlgebraExtension("difference", "{builtin: (difference s1 s2 ...) - elements of s1 in no other set}", Set.Difference)
+
+var subsetExtension = Extension{
+	Name: "subset",
+	Man:  "{builtin: (subset s1 s2) - whether every element of s1 is in s2}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 2 {
+			return resultErrStrf("subset requires 2 arguments")
+		}
+		sets, err := setArgs("subset", values)
+		if err != nil {
+			return resultErr(err)
+		}
+		return resultTypedData(boolToBool(sets[0].Subset(sets[1])))
+	},
+}
@@ -0,0 +1,208 @@
+package runtime_ext
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+func stringArg(name string, o Object) (string, error) {
+	s, ok := o.Data().(String)
+	if !ok {
+		return "", fmt.Errorf("%s argument must be a string", name)
+	}
+	return s.Val, nil
+}
+
+// stringArgs - the arguments of a builtin that takes n strings
+func stringArgs(name string, n int, values []Object) ([]string, error) {
+	if len(values) != n {
+		return nil, fmt.Errorf("%s requires %d arguments", name, n)
+	}
+	ss := make([]string, 0, n)
+	for _, v := range values {
+		s, err := stringArg(name, v)
+		if err != nil {
+			return nil, err
+		}
+		ss = append(ss, s)
+	}
+	return ss, nil
+}
+
+func concatStrings(ctx context.Context, values []Object) adt.Result[Object] {
+	ss := make([]string, 0, len(values))
+	n := 0
+	for _, v := range values {
+		s, err := stringArg("concat", v)
+		if err != nil {
+			return resultErr(err)
+		}
+		ss = append(ss, s)
+		n += len(s)
+	}
+	if err := chargeString(ctx, n); err != nil {
+		return resultErr(err)
+	}
+	return resultTypedData(String{strings.Join(ss, "")})
+}
+
+// makeStringExtension - a builtin of n strings
+func makeStringExtension(name string, man string, n int, f func(ss ...string) TypedData) Extension {
+	return Extension{
+		Name: Name(name),
+		Man:  man,
+		Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+			ss, err := stringArgs(name, n, values)
+			if err != nil {
+				return resultErr(err)
+			}
+			return resultNew(ctx, f(ss...))
+		},
+	}
+}
+
+var substringExtension = Extension{
+	Name: "substring",
+	Man:  "{builtin: (substring s i j) - the runes of s from index i to j-1, negative indexes count from the end}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 3 {
+			return resultErrStrf("substring requires 3 arguments")
+		}
+		s, err := stringArg("substring", values[0])
+		if err != nil {
+			return resultErr(err)
+		}
+		runes := []rune(s)
+		var bounds [2]int
+		for k, v := range values[1:] {
+			i, err := intArg("substring", v)
+			if err != nil {
+				return resultErr(err)
+			}
+			j := i
+			if j < 0 {
+				j += len(runes)
+			}
+			if j < 0 || j > len(runes) {
+				return resultErrStrf("substring index %d out of range for string of length %d", i, len(runes))
+			}
+			bounds[k] = j
+		}
+		if bounds[0] > bounds[1] {
+			return resultErrStrf("substring start %s is after end %s", values[1], values[2])
+		}
+		return resultNew(ctx, String{string(runes[bounds[0]:bounds[1]])})
+	},
+}
+
+var splitExtension = Extension{
+	Name: "split",
+	Man:  "{builtin: (split s sep) - the list of substrings of s separated by sep}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		ss, err := stringArgs("split", 2, values)
+		if err != nil {
+			return resultErr(err)
+		}
+		parts := strings.Split(ss[0], ss[1])
+		if err := chargeList(ctx, len(parts)); err != nil {
+			return resultErr(err)
+		}
+		output := List{}
+		for _, part := range parts {
+			if err := chargeString(ctx, len(part)); err != nil {
+				return resultErr(err)
+			}
+			output = List{output.PushBack(makeTypedData(String{part}))}
+		}
+		return resultTypedData(output)
+	},
+}
+
+var joinExtension = Extension{
+	Name: "join",
+	Man:  "{builtin: (join l sep) - the strings of list l separated by sep}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 2 {
+			return resultErrStrf("join requires 2 arguments")
+		}
+		l, err := listArg(ctx, "join", values[0])
+		if err != nil {
+			return resultErr(err)
+		}
+		sep, err := stringArg("join", values[1])
+		if err != nil {
+			return resultErr(err)
+		}
+		parts := make([]string, 0, l.Len())
+		for _, o := range l.Iter {
+			part, err := stringArg("join", o)
+			if err != nil {
+				return resultErrStrf("join elements must be strings")
+			}
+			parts = append(parts, part)
+		}
+		return resultNew(ctx, String{strings.Join(parts, sep)})
+	},
+}
+
+var replaceExtension = makeStringExtension("replace", "{builtin: (replace s old new) - s with every old replaced by new}", 3,
+	func(ss ...string) TypedData { return String{strings.ReplaceAll(ss[0], ss[1], ss[2])} },
+)
+
+var containsExtension = makeStringExtension("contains", "{builtin: (contains s sub) - whether sub is in s}", 2,
+	func(ss ...string) TypedData { return boolToBool(strings.Contains(ss[0], ss[1])) },
+)
+
+var startsWithExtension = makeStringExtension("starts_with", "{builtin: (starts_with s prefix) - whether s begins with prefix}", 2,
+	func(ss ...string) TypedData { return boolToBool(strings.HasPrefix(ss[0], ss[1])) },
+)
+
+var endsWithExtension = makeStringExtension("ends_with", "{builtin: (ends_with s suffix) - whether s ends with suffix}", 2,
+	func(ss ...string) TypedData { return boolToBool(strings.HasSuffix(ss[0], ss[1])) },
+)
+
+var upperExtension = makeStringExtension("upper", "{builtin: (upper s) - s in upper case}", 1,
+	func(ss ...string) TypedData { return String{strings.ToUpper(ss[0])} },
+)
+
+var lowerExtension = makeStringExtension("lower", "{builtin: (lower s) - s in lower case}", 1,
+	func(ss ...string) TypedData { return String{strings.ToLower(ss[0])} },
+)
+
+var trimExtension = makeStringExtension("trim", "{builtin: (trim s) - s without leading and trailing white space}", 1,
+	func(ss ...string) TypedData { return String{strings.TrimSpace(ss[0])} },
+)
+
+var toStringExtension = Extension{
+	Name: "to_string",
+	Man:  "{builtin: (to_string v) - v as it is printed}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 1 {
+			return resultErrStrf("to_string requires 1 argument")
+		}
+		if s, ok := values[0].Data().(String); ok {
+			return resultTypedData(s)
+		}
+		return resultNew(ctx, String{fmt.Sprint(values[0])})
+	},
+}
+
+var parseIntExtension = Extension{
+	Name: "parse_int",
+	Man:  "{builtin: (parse_int s) - the integer written in s}",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		ss, err := stringArgs("parse_int", 1, values)
+		if err != nil {
+			return resultErr(err)
+		}
+		i, err := strconv.Atoi(strings.TrimSpace(ss[0]))
+		if err != nil {
+			return resultErrStrf("parse_int: invalid integer %q", ss[0])
+		}
+		return resultTypedData(Int{i})
+	},
+}
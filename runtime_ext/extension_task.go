@@ -0,0 +1,242 @@
+package runtime_ext
+
+import (
+	"context"
+	"el/ast"
+	"el/runtime"
+	"fmt"
+	"reflect"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+func init() {
+	runtime.RegisterSpecialForm("select", selectFunc)
+}
+
+func chanArg(name string, o Object) (Chan, error) {
+	c, ok := o.Data().(Chan)
+	if !ok {
+		return Chan{}, fmt.Errorf("%s argument must be a chan", name)
+	}
+	return c, nil
+}
+
+var spawnExtension = HigherOrderExtension{
+	Name: "spawn",
+	Man:  "[builtin: (spawn f x y ...) - run (f x y ...) on its own goroutine and return a task, e.g. (spawn {=> (work)})]",
+	Exec: func(ctx context.Context, call Caller, values ...Object) adt.Result[Object] {
+		if len(values) == 0 {
+			return resultErrStrf("spawn requires at least 1 argument")
+		}
+		f, args := values[0], values[1:]
+		return resultTypedData(spawnTask(ctx, f.String(), func(ctx context.Context) adt.Result[Object] {
+			return call(ctx, f, args...)
+		}))
+	},
+}
+
+var awaitExtension = Extension{
+	Name: "await",
+	Man:  "[builtin: (await t) - wait for task t and return its result or its error]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 1 {
+			return resultErrStrf("await requires 1 argument")
+		}
+		t, ok := values[0].Data().(Task)
+		if !ok {
+			return resultErrStrf("await argument must be a task")
+		}
+		return t.await(ctx)
+	},
+}
+
+var chanExtension = Extension{
+	Name: "chan",
+	Man:  "[builtin: (chan n) - a chan with a buffer of n values, (chan) is unbuffered]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) > 1 {
+			return resultErrStrf("chan requires at most 1 argument")
+		}
+		size := 0
+		if len(values) == 1 {
+			i, ok := values[0].Data().(Int)
+			if !ok || i.Val < 0 {
+				return resultErrStrf("chan size must be a non-negative integer")
+			}
+			size = i.Val
+		}
+		return resultNew(ctx, makeChan(size))
+	},
+}
+
+var sendExtension = Extension{
+	Name: "send",
+	Man:  "[builtin: (send c x) - send x on chan c, block until it is received or buffered]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 2 {
+			return resultErrStrf("send requires 2 arguments")
+		}
+		c, err := chanArg("send", values[0])
+		if err != nil {
+			return resultErr(err)
+		}
+		if err := c.send(ctx, values[1]); err != nil {
+			return resultErr(err)
+		}
+		return resultObj(makeNil())
+	},
+}
+
+var recvExtension = Extension{
+	Name: "recv",
+	Man:  "[builtin: (recv c) - receive a value from chan c, nil once c is closed and empty]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 1 {
+			return resultErrStrf("recv requires 1 argument")
+		}
+		c, err := chanArg("recv", values[0])
+		if err != nil {
+			return resultErr(err)
+		}
+		o, err := c.recv(ctx)
+		if err != nil {
+			return resultErr(err)
+		}
+		return resultObj(o)
+	},
+}
+
+var closeExtension = Extension{
+	Name: "close",
+	Man:  "[builtin: (close c) - close chan c, later sends are errors]",
+	Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+		if len(values) != 1 {
+			return resultErrStrf("close requires 1 argument")
+		}
+		c, err := chanArg("close", values[0])
+		if err != nil {
+			return resultErr(err)
+		}
+		if err := c.close(); err != nil {
+			return resultErr(err)
+		}
+		return resultObj(makeNil())
+	},
+}
+
+// selectClause - one clause of select, after its chan and value are evaluated
+type selectClause struct {
+	kind  ast.Name // recv, send or default
+	c     Chan
+	value Object   // the value to send
+	name  ast.Name // the name bound to the received value
+	body  ast.Expr
+}
+
+var errSelectClause = fmt.Errorf("select clause must be (recv c x body), (send c x body) or (default body)")
+
+// parseSelectClause - the chans and sent values are evaluated in order before select blocks
+func parseSelectClause(r Runtime, ctx context.Context, frame Frame, e ast.Expr) (selectClause, error) {
+	l, ok := e.(ast.Lambda)
+	if !ok || len(l) == 0 {
+		return selectClause{}, errSelectClause
+	}
+	kind, _ := l[0].(ast.Name)
+	switch {
+	case kind == "default" && len(l) == 2:
+		return selectClause{kind: kind, body: l[1]}, nil
+	case (kind == "recv" || kind == "send") && len(l) == 4:
+		clause := selectClause{kind: kind, body: l[3]}
+		var o Object
+		if err := r.Step(ctx, frame, l[1]).Unwrap(&o); err != nil {
+			return clause, err
+		}
+		c, err := chanArg(fmt.Sprintf("select %s", kind), o)
+		if err != nil {
+			return clause, err
+		}
+		clause.c = c
+		if kind == "send" {
+			if c.isClosed() {
+				return clause, ErrorSendOnClosedChan
+			}
+			if err := r.Step(ctx, frame, l[2]).Unwrap(&clause.value); err != nil {
+				return clause, err
+			}
+			if clause.value == nil {
+				clause.value = makeNil()
+			}
+			return clause, nil
+		}
+		name, ok := l[2].(ast.Name)
+		if !ok {
+			return clause, fmt.Errorf("lvalue must be a Name: %s", l[2].String())
+		}
+		if _, ok := runtime.SpecialForm(Name(name)); ok {
+			return clause, runtime.ErrorRebindSpecialForm(Name(name))
+		}
+		clause.name = name
+		return clause, nil
+	default:
+		return selectClause{}, errSelectClause
+	}
+}
+
+var selectFunc = runtime.FuncData{
+	Repr: "{builtin: (select (recv c x body) (send c y body) (default body)) - run the body of the first clause whose chan is ready, block unless there is a default}",
+	Exec: func(r Runtime, ctx context.Context, frame Frame, argExprList []ast.Expr) adt.Result[Object] {
+		if len(argExprList) == 0 {
+			return resultErrStrf("select requires at least 1 clause")
+		}
+		// the first case is the interrupt of ctx, every clause has a case for its chan and one for its close
+		cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}}
+		var clauses []selectClause
+		var defaultClause *selectClause
+		for _, e := range argExprList {
+			clause, err := parseSelectClause(r, ctx, frame, e)
+			if err != nil {
+				return resultErr(err)
+			}
+			switch clause.kind {
+			case "default":
+				if defaultClause != nil {
+					return resultErrStrf("select has more than 1 default clause")
+				}
+				defaultClause = &clause
+				continue
+			case "recv":
+				cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(clause.c.ch)})
+			case "send":
+				cases = append(cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(clause.c.ch), Send: reflect.ValueOf(&clause.value).Elem()})
+			}
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(clause.c.closed)})
+			clauses = append(clauses, clause)
+		}
+		if defaultClause != nil {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+		}
+
+		chosen, received, _ := reflect.Select(cases)
+		if chosen == 0 {
+			return resultErr(runtime.CheckInterrupt(ctx))
+		}
+		if chosen == len(clauses)*2+1 {
+			return r.Step(ctx, frame, defaultClause.body)
+		}
+		clause, closed := clauses[(chosen-1)/2], (chosen-1)%2 == 1
+		switch {
+		case clause.kind == "send" && closed:
+			return resultErr(ErrorSendOnClosedChan)
+		case clause.kind == "recv" && closed:
+			frame = frame.Set(Name(clause.name), clause.c.drain())
+		case clause.kind == "recv":
+			o, ok := received.Interface().(Object)
+			if !ok || o == nil {
+				return resultErrStrf("select received %v from %s, which is not a value", received, clause.c)
+			}
+			frame = frame.Set(Name(clause.name), o)
+		}
+		return r.Step(ctx, frame, clause.body)
+	},
+}
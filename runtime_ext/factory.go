@@ -1,6 +1,7 @@
 package runtime_ext
 
 import (
+	"context"
 	"el/ast"
 	"el/runtime"
 	"encoding/json"
@@ -13,7 +14,9 @@ import (
 type Runtime = runtime.Runtime
 type Frame = runtime.Frame
 
-func NewBasicRuntime() (Runtime, Frame) {
+// NewBasicRuntime - the runtime and the frame of the pure builtins and the builtins of the granted capabilities,
+// e.g. NewBasicRuntime(GrantIO(), GrantFSRead("data")), a script without grants cannot reach the host
+func NewBasicRuntime(caps ...Capability) (Runtime, Frame) {
 	r := Runtime{
 		ParseLiteral: func(lit string) adt.Result[Object] {
 			val, err := parseLiteral(lit)
@@ -22,31 +25,65 @@ func NewBasicRuntime() (Runtime, Frame) {
 				Err: err,
 			}
 		},
-		UnwrapArgs: func(argsOpt adt.Result[[]Object]) adt.Result[[]Object] {
+		UnwrapArgs: func(ctx context.Context, argsOpt adt.Result[[]Object]) adt.Result[[]Object] {
 			var args []Object
 			if err := argsOpt.Unwrap(&args); err != nil {
 				return adt.Result[[]Object]{
 					Err: err,
 				}
 			}
-			unwrappedArgs, err := unwrapArgs(args)
+			unwrappedArgs, err := unwrapArgs(ctx, args)
 			return adt.Result[[]Object]{
 				Val: unwrappedArgs,
 				Err: err,
 			}
 		},
+		MakeList: func(elems []Object) Object {
+			return makeTypedData(List{List{}.PushBack(elems...)})
+		},
 	}
 	f :=
 		(&frameHelper{frame: runtime.Builtin}).
 			LoadExtension(listExtension, lenExtension, sliceExtension, rangeExtension).
+			LoadExtension(deleteExtension, concatExtension, splitAtExtension, reverseExtension).
+			LoadExtension(pushExtension, popExtension, takeExtension, dropExtension).
 			Load("true", makeTypedData(True)).Load("false", makeTypedData(False)).
 			Load("int_type", runtime.MakeType("int")).
+			Load("float_type", runtime.MakeType("float")).
 			Load("list_type", runtime.MakeType("list")).
 			Load("string_type", runtime.MakeType("string")).
+			Load("dict_type", runtime.MakeType("dict")).
+			Load("set_type", runtime.MakeType("set")).
 			Load("names", runtime.MakeData(namesFunc, runtime.BuiltinType)).
 			LoadExtension(eqExtension, neExtension, ltExtension, leExtension, gtExtension, geExtension).
 			LoadExtension(addExtension, subExtension, mulExtension, divExtension, modExtension).
-			LoadExtension(printExtension, inspectExtension)
+			LoadExtension(negExtension, andExtension, orExtension, notExtension).
+			LoadExtension(dictExtension, getExtension, setExtension, delExtension, hasExtension).
+			LoadExtension(keysExtension, valuesExtension, itemsExtension, mergeExtension).
+			LoadExtension(setOfExtension, toSetExtension, toListExtension, insertExtension).
+			LoadExtension(unionExtension, intersectExtension, differenceExtension, subsetExtension).
+			LoadHigherOrderExtension(mapExtension, filterExtension, foldLeftExtension, foldRightExtension).
+			LoadHigherOrderExtension(anyExtension, allExtension, findExtension, sortByExtension, groupByExtension).
+			LoadExtension(repeatExtension, zipExtension).
+			LoadHigherOrderExtension(iterateExtension, takeWhileExtension, mapLazyExtension, filterLazyExtension).
+			Load("seq_type", runtime.MakeType("seq")).
+			LoadExtension(substringExtension, splitExtension, joinExtension, replaceExtension, containsExtension).
+			LoadExtension(startsWithExtension, endsWithExtension, upperExtension, lowerExtension, trimExtension).
+			LoadExtension(toStringExtension, parseIntExtension).
+			LoadExtension(jsonParseExtension, jsonStringifyExtension).
+			LoadHigherOrderExtension(spawnExtension).
+			LoadParallelExtension(pmapExtension).
+			LoadExtension(awaitExtension, chanExtension, sendExtension, recvExtension, closeExtension).
+			Load("select", runtime.MakeData(selectFunc, runtime.BuiltinType)).
+			Load("task_type", runtime.MakeType("task")).
+			Load("chan_type", runtime.MakeType("chan"))
+
+	for _, c := range caps {
+		for _, ext := range c.Extensions {
+			f.LoadExtension(c.audited(ext))
+		}
+	}
+	f.LoadExtension(makeCapabilitiesExtension(caps))
 
 	return r, f.frame
 }
@@ -66,27 +103,60 @@ func (sh *frameHelper) LoadExtension(exts ...Extension) *frameHelper {
 	}
 	return sh
 }
-func unwrapArgs(args []Object) ([]Object, error) {
+
+func (sh *frameHelper) LoadHigherOrderExtension(exts ...HigherOrderExtension) *frameHelper {
+	for _, ext := range exts {
+		sh.Load(ext.Name, runtime.MakeData(ext.Module(), runtime.BuiltinType))
+	}
+	return sh
+}
+
+func (sh *frameHelper) LoadParallelExtension(exts ...ParallelExtension) *frameHelper {
+	for _, ext := range exts {
+		sh.Load(ext.Name, runtime.MakeData(ext.Module(), runtime.BuiltinType))
+	}
+	return sh
+}
+
+// unwrapArgs - spread the list or the seq after every $ into the arguments, a seq is materialized as with listArg
+func unwrapArgs(ctx context.Context, args []Object) ([]Object, error) {
 	var unwrapArgsLoop func(args []Object) ([]Object, bool, error)
 	unwrapArgsLoop = func(args []Object) ([]Object, bool, error) {
 		unwrapped := false
 		unwrappedArgs := make([]Object, 0, len(args))
 		for len(args) > 0 {
 			head := args[0]
+			if head == nil {
+				// the value of e.g. (print), it is an argument as any other
+				unwrappedArgs = append(unwrappedArgs, head)
+				args = args[1:]
+				continue
+			}
 			if _, ok := head.Data().(Unwrap); ok {
 				if len(args) <= 1 {
 					return unwrappedArgs, unwrapped, errors.New("unwrapping argument empty")
 				}
+				if args[1] == nil {
+					return unwrappedArgs, unwrapped, errors.New("unwrapping argument must be a list, a seq or an unwrap")
+				}
 				switch next := args[1].Data().(type) {
 				case List:
 					unwrappedArgs = append(unwrappedArgs, next.Repr()...)
 					args = args[2:]
 					unwrapped = true
+				case LazySeq:
+					l, err := collect(ctx, next)
+					if err != nil {
+						return unwrappedArgs, unwrapped, err
+					}
+					unwrappedArgs = append(unwrappedArgs, l.Repr()...)
+					args = args[2:]
+					unwrapped = true
 				case Unwrap: // nested unwrap
 					unwrappedArgs = append(unwrappedArgs, head)
 					args = args[1:]
 				default:
-					return unwrappedArgs, unwrapped, errors.New("unwrapping argument must be a list or an unwrap")
+					return unwrappedArgs, unwrapped, errors.New("unwrapping argument must be a list, a seq or an unwrap")
 				}
 			} else {
 				unwrappedArgs = append(unwrappedArgs, head)
@@ -0,0 +1,109 @@
+package runtime_ext
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// IO - the streams used by the IO builtins, carried by the context so that
+// every evaluation, e.g. every request of a server, can have its own
+type IO struct {
+	Stdin  *LockedReader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func NewIO(stdin io.Reader, stdout io.Writer, stderr io.Writer) IO {
+	return IO{
+		Stdin:  &LockedReader{r: bufio.NewReader(stdin)},
+		Stdout: stdout,
+		Stderr: stderr,
+	}
+}
+
+// LockedReader - the buffered stdin of an evaluation, shared by the tasks it spawns,
+// every read holds the lock so that a line or the rest of the input goes to one task
+type LockedReader struct {
+	mu sync.Mutex
+	r  *bufio.Reader
+}
+
+func (r *LockedReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.r.Read(p)
+}
+
+// ReadString - read up to and including delim, as bufio.Reader.ReadString
+func (r *LockedReader) ReadString(delim byte) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.r.ReadString(delim)
+}
+
+// ReadAll - read the rest of the input, as io.ReadAll, in one hold of the lock
+func (r *LockedReader) ReadAll() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return io.ReadAll(r.r)
+}
+
+type ioKey struct{}
+
+// WithIO - run the IO builtins of evaluations under ctx on the given streams
+func WithIO(ctx context.Context, streams IO) context.Context {
+	return context.WithValue(ctx, ioKey{}, streams)
+}
+
+var processIO = sync.OnceValue(func() IO {
+	return NewIO(os.Stdin, os.Stdout, os.Stderr)
+})
+
+// IOFrom - the streams of ctx, the process streams by default
+func IOFrom(ctx context.Context) IO {
+	if streams, ok := ctx.Value(ioKey{}).(IO); ok {
+		return streams
+	}
+	return processIO()
+}
+
+// Captured - the output of evaluations run under the context returned by Capture
+type Captured struct {
+	stdout lockedBuffer
+	stderr lockedBuffer
+}
+
+func (c *Captured) Stdout() string {
+	return c.stdout.String()
+}
+
+func (c *Captured) Stderr() string {
+	return c.stderr.String()
+}
+
+// Capture - a context whose IO reads the given stdin and records stdout and stderr, for tests and servers
+func Capture(ctx context.Context, stdin string) (context.Context, *Captured) {
+	c := &Captured{}
+	return WithIO(ctx, NewIO(bytes.NewBufferString(stdin), &c.stdout, &c.stderr)), c
+}
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
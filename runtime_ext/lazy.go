@@ -0,0 +1,90 @@
+package runtime_ext
+
+import (
+	"context"
+	"el/runtime"
+	"fmt"
+	"iter"
+)
+
+// LazySeq - a lazy sequence, its elements are computed each time it is iterated
+// builtins that need a list materialize it, the rest stream it in constant memory
+type LazySeq struct {
+	repr string
+	all  func(ctx context.Context) iter.Seq2[Object, error]
+}
+
+func (s LazySeq) String() string {
+	return fmt.Sprintf("seq{%s}", s.repr)
+}
+
+func (s LazySeq) TypeName() string {
+	return "seq"
+}
+
+// makeLazySeq - make a sequence from a generator, the generator stops when yield returns false or on error
+// the context is checked before every element so that unbounded sequences can be interrupted
+func makeLazySeq(repr string, gen func(ctx context.Context, yield func(Object) bool) error) LazySeq {
+	return LazySeq{
+		repr: repr,
+		all: func(ctx context.Context) iter.Seq2[Object, error] {
+			return func(yield func(Object, error) bool) {
+				var interrupt error
+				stopped := false
+				err := gen(ctx, func(o Object) bool {
+					if interrupt = runtime.CheckInterrupt(ctx); interrupt != nil {
+						return false
+					}
+					if !yield(o, nil) {
+						stopped = true
+						return false
+					}
+					return true
+				})
+				if interrupt != nil {
+					err = interrupt
+				}
+				if err != nil && !stopped {
+					yield(nil, err)
+				}
+			}
+		},
+	}
+}
+
+// elements - iterate a list or a lazy sequence
+func elements(ctx context.Context, name string, o Object) (iter.Seq2[Object, error], error) {
+	switch data := o.Data().(type) {
+	case List:
+		return func(yield func(Object, error) bool) {
+			for _, x := range data.Iter {
+				if !yield(x, nil) {
+					return
+				}
+			}
+		}, nil
+	case LazySeq:
+		return data.all(ctx), nil
+	default:
+		return nil, fmt.Errorf("%s argument must be a list or a seq", name)
+	}
+}
+
+// collect - materialize a lazy sequence
+func collect(ctx context.Context, s LazySeq) (List, error) {
+	if err := chargeList(ctx, 0); err != nil {
+		return List{}, err
+	}
+	l := List{}
+	for x, err := range s.all(ctx) {
+		if err != nil {
+			return List{}, err
+		}
+		// charged for every element before it is added, a materialized seq can be unbounded
+		if err := chargeListElems(ctx, 1); err != nil {
+			return List{}, err
+		}
+		l = List{l.PushBack(x)}
+	}
+	return l, nil
+}
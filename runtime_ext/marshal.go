@@ -0,0 +1,49 @@
+package runtime_ext
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ToObject - convert a Go value into an el object, modelled on json.Marshal
+//
+//	integers and bools -> int (bools are true and false)
+//	floats -> float
+//	strings -> string
+//	slices and arrays -> list
+//	maps and structs -> dict, struct fields are named by their `el:"name,omitempty"` tag or their Go name
+//	pointers -> the object pointed to, nil -> nil
+//	Object -> itself
+func ToObject(v any) (Object, error) {
+	if v == nil {
+		return makeNil(), nil
+	}
+	encode, err := encoderOf(reflect.TypeOf(v))
+	if err != nil {
+		return nil, err
+	}
+	return encode(reflect.ValueOf(v))
+}
+
+// FromObject - convert an el object into the Go value pointed to by ptr, modelled on json.Unmarshal
+// dict entries without a matching struct field are ignored, fields without a matching entry are zero
+func FromObject(o Object, ptr any) error {
+	pv := reflect.ValueOf(ptr)
+	if pv.Kind() != reflect.Pointer || pv.IsNil() {
+		return errors.New("FromObject requires a non-nil pointer")
+	}
+	decode, err := decoderOf(pv.Type().Elem())
+	if err != nil {
+		return err
+	}
+	if o == nil {
+		o = makeNil()
+	}
+	v, err := decode(o)
+	if err != nil {
+		return fmt.Errorf("FromObject: %w", err)
+	}
+	pv.Elem().Set(v)
+	return nil
+}
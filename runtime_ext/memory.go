@@ -0,0 +1,47 @@
+package runtime_ext
+
+import (
+	"context"
+	"el/runtime"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+// the charges of the data a builtin makes, as the Size of the data,
+// a builtin charges before it builds, per element when it does not know the length,
+// what the result shares with the arguments, e.g. the rest of a list after push, is not charged again
+
+// chargeList - a new list of n elements
+func chargeList(ctx context.Context, n int) error {
+	return runtime.Charge(ctx, List{}.Size()+16*int64(n))
+}
+
+// chargeListElems - n more elements of a list being built
+func chargeListElems(ctx context.Context, n int) error {
+	return runtime.Charge(ctx, 16*int64(n))
+}
+
+// chargeDict - a new dict of n entries
+func chargeDict(ctx context.Context, n int) error {
+	return runtime.Charge(ctx, Dict{}.Size()+48*int64(n))
+}
+
+// chargeSet - a new set of n elements
+func chargeSet(ctx context.Context, n int) error {
+	return runtime.Charge(ctx, Set{}.Size()+32*int64(n))
+}
+
+// chargeString - a new string of n bytes
+func chargeString(ctx context.Context, n int) error {
+	return runtime.Charge(ctx, String{}.Size()+int64(n))
+}
+
+// resultNew - new data that is made before it can be charged, e.g. by the strings package, charged by its size
+func resultNew(ctx context.Context, data TypedData) adt.Result[Object] {
+	if s, ok := data.(runtime.Sizer); ok {
+		if err := runtime.Charge(ctx, s.Size()); err != nil {
+			return resultErr(err)
+		}
+	}
+	return resultTypedData(data)
+}
@@ -3,6 +3,7 @@ package runtime_ext
 import (
 	"el/runtime"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/fbundle/lab_public/lab/go_util/pkg/persistent/seq"
@@ -39,6 +40,19 @@ func (i Int) TypeName() string {
 	return "int"
 }
 
+// Float - a number that is not an integer, e.g. from json_parse, arithmetic and comparison builtins take Int only,
+// match compares floats by value with floats, a float never matches an int
+type Float struct {
+	Val float64
+}
+
+func (f Float) String() string {
+	return strconv.FormatFloat(f.Val, 'g', -1, 64)
+}
+func (f Float) TypeName() string {
+	return "float"
+}
+
 type List struct {
 	seq.Seq[Object]
 }
@@ -56,6 +70,17 @@ func (l List) TypeName() string {
 	return "list"
 }
 
+// Size - 16 bytes per element and the header, the elements are charged when they are made
+func (l List) Size() int64 {
+	return 32 + 16*int64(l.Len())
+}
+
+// Equal - lists are equal if they have equal elements
+func (l List) Equal(other Data) bool {
+	o, ok := other.(List)
+	return ok && compareObject(makeTypedData(l), makeTypedData(o)) == 0
+}
+
 type String struct {
 	Val string
 }
@@ -67,3 +92,7 @@ func (s String) String() string {
 func (s String) TypeName() string {
 	return "string"
 }
+
+func (s String) Size() int64 {
+	return 16 + int64(len(s.Val))
+}
@@ -0,0 +1,76 @@
+package runtime_ext
+
+import (
+	"cmp"
+	"el/runtime"
+	"fmt"
+)
+
+// checkOrdered - whether an object has a total order, i.e. it can be a key of a Dict
+// ints, floats, strings, nil and lists, dicts and sets of those are ordered, functions and types are not
+func checkOrdered(o Object) error {
+	if o == nil {
+		return nil
+	}
+	switch data := o.Data().(type) {
+	case Int, Float, String, runtime.Nil:
+		return nil
+	case List:
+		for _, elem := range data.Iter {
+			if err := checkOrdered(elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Dict:
+		for k, v := range data.Iter {
+			if err := checkOrdered(k); err != nil {
+				return err
+			}
+			if err := checkOrdered(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Set:
+		return nil // elements are checked on insert
+	default:
+		return fmt.Errorf("%s of type %s has no order", o, typeNameOf(o))
+	}
+}
+
+// compareObject - the total order of ordered objects,
+// objects of different types are ordered by type name, lists, dicts and sets lexicographically
+func compareObject(o1 Object, o2 Object) int {
+	if c := cmp.Compare(typeNameOf(o1), typeNameOf(o2)); c != 0 {
+		return c
+	}
+	if o1 == nil || o2 == nil {
+		return 0
+	}
+	switch d1 := o1.Data().(type) {
+	case Int:
+		return cmp.Compare(d1.Val, o2.Data().(Int).Val)
+	case Float:
+		return cmp.Compare(d1.Val, o2.Data().(Float).Val)
+	case String:
+		return cmp.Compare(d1.Val, o2.Data().(String).Val)
+	case List:
+		d2 := o2.Data().(List)
+		for i := 0; i < min(d1.Len(), d2.Len()); i++ {
+			if c := compareObject(d1.Get(i), d2.Get(i)); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(d1.Len(), d2.Len())
+	case Dict:
+		l1, l2 := d1.items(), o2.Data().(Dict).items()
+		return compareObject(makeTypedData(l1), makeTypedData(l2))
+	case Set:
+		l1, l2 := d1.list(), o2.Data().(Set).list()
+		return compareObject(makeTypedData(l1), makeTypedData(l2))
+	default:
+		// not ordered, keep the order total anyway
+		return cmp.Compare(o1.String(), o2.String())
+	}
+}
@@ -0,0 +1,210 @@
+package runtime_ext
+
+import (
+	"context"
+	"el/runtime"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+// Register - bind a plain Go function as a builtin, arguments and results are converted by reflection
+//
+//	parameters: an optional leading context.Context, then values convertible by FromObject,
+//	  the last parameter may be variadic
+//	results: nothing, a value convertible by ToObject, an error, or a value and an error
+//
+// map parameters also accept lists of [key value] pairs
+// the type of the builtin is the arrow type of its parameters and result, a variadic parameter counts once
+func Register(frame Frame, name Name, f any) (Frame, error) {
+	o, err := makeGoFunc(name, f, false)
+	if err != nil {
+		return frame, err
+	}
+	return frame.Set(name, o), nil
+}
+
+// RegisterKeywords - Register a Go function whose last parameter receives the keyword arguments :name value of a call,
+// converted from a dict by FromObject, e.g. a map[string]int or a struct with `el` tags
+// a struct parameter rejects keywords without a matching field, the parameter is zero if there are no keyword arguments
+func RegisterKeywords(frame Frame, name Name, f any) (Frame, error) {
+	o, err := makeGoFunc(name, f, true)
+	if err != nil {
+		return frame, err
+	}
+	return frame.Set(name, o), nil
+}
+
+// keywordDict - the positional arguments and the dict of the keyword arguments of a builtin with Keywords set
+func keywordDict(values []Object) ([]Object, Dict) {
+	values, keywords := runtime.SplitKeywords(values)
+	d := Dict{}
+	for i, name := range keywords.Names {
+		d = d.Set(makeTypedData(String{Val: string(name)}), keywords.Values[i])
+	}
+	return values, d
+}
+
+func makeGoFunc(name Name, f any, withKeywords bool) (Object, error) {
+	fv := reflect.ValueOf(f)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func {
+		return nil, fmt.Errorf("register %s: %s is not a function", name, ft)
+	}
+
+	// parameters
+	withContext := ft.NumIn() > 0 && ft.In(0) == contextType
+	var paramTypes []reflect.Type
+	for i := 0; i < ft.NumIn(); i++ {
+		if i == 0 && withContext {
+			continue
+		}
+		paramTypes = append(paramTypes, ft.In(i))
+	}
+	var decodeKeywords decoder
+	var keywordNames map[string]bool // nil if any keyword is accepted
+	if withKeywords {
+		if len(paramTypes) == 0 || ft.IsVariadic() {
+			return nil, fmt.Errorf("register %s: the last parameter must take the keyword arguments and cannot be variadic", name)
+		}
+		t := paramTypes[len(paramTypes)-1]
+		paramTypes = paramTypes[:len(paramTypes)-1]
+		var err error
+		if decodeKeywords, err = decoderOf(t); err != nil {
+			return nil, fmt.Errorf("register %s: keyword parameter: %w", name, err)
+		}
+		if t.Kind() == reflect.Struct {
+			fields, err := fieldsOf(t)
+			if err != nil {
+				return nil, fmt.Errorf("register %s: keyword parameter: %w", name, err)
+			}
+			keywordNames = map[string]bool{}
+			for _, f := range fields {
+				keywordNames[f.name] = true
+			}
+		}
+	}
+	decoders := make([]decoder, 0, len(paramTypes))
+	for i, t := range paramTypes {
+		if i == len(paramTypes)-1 && ft.IsVariadic() {
+			t = t.Elem()
+		}
+		decode, err := decoderOf(t)
+		if err != nil {
+			return nil, fmt.Errorf("register %s: parameter %d: %w", name, i, err)
+		}
+		decoders = append(decoders, decode)
+	}
+
+	// results
+	withError := ft.NumOut() > 0 && ft.Out(ft.NumOut()-1) == errorType
+	var resultType reflect.Type
+	switch {
+	case ft.NumOut() == 0, ft.NumOut() == 1 && withError:
+	case ft.NumOut() == 1, ft.NumOut() == 2 && withError:
+		resultType = ft.Out(0)
+	default:
+		return nil, fmt.Errorf("register %s: results must be a value, an error or a value and an error", name)
+	}
+	encode := func(reflect.Value) (Object, error) {
+		return makeNil(), nil
+	}
+	if resultType != nil {
+		var err error
+		if encode, err = encoderOf(resultType); err != nil {
+			return nil, fmt.Errorf("register %s: result: %w", name, err)
+		}
+	}
+
+	ext := Extension{
+		Name:     name,
+		Man:      fmt.Sprintf("{builtin: %s - go %s}", name, ft),
+		Keywords: withKeywords,
+		Exec: func(ctx context.Context, values ...Object) adt.Result[Object] {
+			var keywords Dict
+			values, keywords = keywordDict(values)
+			if ft.IsVariadic() {
+				if len(values) < len(decoders)-1 {
+					return resultErrStrf("%s requires at least %d arguments", name, len(decoders)-1)
+				}
+			} else if len(values) != len(decoders) {
+				return resultErrStrf("%s requires %d arguments", name, len(decoders))
+			}
+			in := make([]reflect.Value, 0, ft.NumIn()+len(values))
+			if withContext {
+				in = append(in, reflect.ValueOf(&ctx).Elem())
+			}
+			for i, value := range values {
+				if value == nil {
+					value = makeNil()
+				}
+				decode := decoders[min(i, len(decoders)-1)]
+				v, err := decode(value)
+				if err != nil {
+					return resultErrStrf("%s argument %d: %w", name, i, err)
+				}
+				in = append(in, v)
+			}
+			if withKeywords {
+				for k := range keywords.Iter {
+					if keywordNames != nil && !keywordNames[k.String()] {
+						return resultErrStrf("%s: unknown keyword argument %s", name, k)
+					}
+				}
+				v, err := decodeKeywords(makeTypedData(keywords))
+				if err != nil {
+					return resultErrStrf("%s keyword arguments: %w", name, err)
+				}
+				in = append(in, v)
+			}
+			out := fv.Call(in)
+			if withError {
+				if err, _ := out[len(out)-1].Interface().(error); err != nil {
+					return resultErr(err)
+				}
+			}
+			var result reflect.Value
+			if resultType != nil {
+				result = out[0]
+			}
+			o, err := encode(result)
+			if err != nil {
+				return resultErrStrf("%s result: %w", name, err)
+			}
+			// converted from Go, the result shares nothing with the arguments
+			if err := runtime.Charge(ctx, runtime.SizeOf(o)); err != nil {
+				return resultErr(err)
+			}
+			return resultObj(o)
+		},
+	}
+
+	funcSort, err := goFuncSort(paramTypes, ft.IsVariadic(), resultType)
+	if err != nil {
+		return nil, fmt.Errorf("register %s: %w", name, err)
+	}
+	return runtime.MakeData(ext.Module(), runtime.MakeSort(funcSort)), nil
+}
+
+// goFuncSort - the arrow sort of a Go signature, e.g. func(int, string) []int is {int -> string -> list}
+func goFuncSort(paramTypes []reflect.Type, variadic bool, resultType reflect.Type) (runtime.Sort, error) {
+	sortList := make([]runtime.Sort, 0, len(paramTypes)+1)
+	for i, t := range paramTypes {
+		if i == len(paramTypes)-1 && variadic {
+			t = t.Elem()
+		}
+		sortList = append(sortList, runtime.MakeType(elTypeName(t)).Sort())
+	}
+	if resultType == nil {
+		sortList = append(sortList, runtime.MakeType(runtime.Unit).Sort())
+	} else {
+		sortList = append(sortList, runtime.MakeType(elTypeName(resultType)).Sort())
+	}
+	var funcSort runtime.Sort
+	if ok := runtime.Arrow(sortList...).Unwrap(&funcSort); !ok {
+		return nil, errors.New("cannot make arrow sort")
+	}
+	return funcSort, nil
+}
@@ -0,0 +1,129 @@
+package runtime_ext
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/persistent/ordered_map"
+)
+
+// Set - persistent set of ordered objects
+type Set struct {
+	elems ordered_map.Map[setEntry]
+}
+
+type setEntry struct {
+	val Object
+}
+
+func (e setEntry) Cmp(o setEntry) int {
+	return compareObject(e.val, o.val)
+}
+
+func (s Set) Has(val Object) bool {
+	_, ok := s.elems.Get(setEntry{val: val})
+	return ok
+}
+
+// Ins - the value must be ordered, see checkOrdered
+func (s Set) Ins(val Object) Set {
+	return Set{elems: s.elems.Set(setEntry{val: val})}
+}
+
+func (s Set) Del(val Object) Set {
+	return Set{elems: s.elems.Del(setEntry{val: val})}
+}
+
+func (s Set) Len() int {
+	return int(s.elems.Len())
+}
+
+// Iter - iterate over the elements in order
+func (s Set) Iter(yield func(val Object) bool) {
+	s.elems.Iter(func(entry setEntry) bool {
+		return yield(entry.val)
+	})
+}
+
+func (s Set) Union(o Set) Set {
+	if s.Len() < o.Len() {
+		s, o = o, s
+	}
+	for val := range o.Iter {
+		s = s.Ins(val)
+	}
+	return s
+}
+
+func (s Set) Intersect(o Set) Set {
+	if s.Len() > o.Len() {
+		s, o = o, s
+	}
+	output := s
+	for val := range s.Iter {
+		if !o.Has(val) {
+			output = output.Del(val)
+		}
+	}
+	return output
+}
+
+func (s Set) Difference(o Set) Set {
+	output := s
+	if s.Len() <= o.Len() {
+		for val := range s.Iter {
+			if o.Has(val) {
+				output = output.Del(val)
+			}
+		}
+	} else {
+		for val := range o.Iter {
+			output = output.Del(val)
+		}
+	}
+	return output
+}
+
+func (s Set) Subset(o Set) bool {
+	if s.Len() > o.Len() {
+		return false
+	}
+	for val := range s.Iter {
+		if !o.Has(val) {
+			return false
+		}
+	}
+	return true
+}
+
+// list - the elements in order
+func (s Set) list() List {
+	l := List{}
+	for val := range s.Iter {
+		l = List{l.PushBack(val)}
+	}
+	return l
+}
+
+func (s Set) String() string {
+	ls := make([]string, 0, s.Len())
+	for val := range s.Iter {
+		ls = append(ls, fmt.Sprint(val))
+	}
+	return fmt.Sprintf("set[%s]", strings.Join(ls, " "))
+}
+
+// Size - an element and a tree node per element
+func (s Set) Size() int64 {
+	return 32 + 32*int64(s.Len())
+}
+
+func (s Set) TypeName() string {
+	return "set"
+}
+
+// Equal - sets are equal if they have equal elements
+func (s Set) Equal(other Data) bool {
+	o, ok := other.(Set)
+	return ok && s.Len() == o.Len() && s.Subset(o)
+}
@@ -0,0 +1,559 @@
+package runtime_ext
+
+import (
+	"el/ast"
+	"el/runtime"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	sorts "github.com/fbundle/sorts/sorts/sorts_v1"
+)
+
+type Sort = runtime.Sort
+
+// SnapshotVersion - the version of the snapshot format written by Snapshot
+const SnapshotVersion = 1
+
+// snapshotJSON - closures are stored once, in an order where a closure only refers to the ones before it
+type snapshotJSON struct {
+	Version  int           `json:"version"`
+	Closures []closureJSON `json:"closures"`
+	Frame    []entryJSON   `json:"frame"`
+}
+
+type closureJSON struct {
+	Name     string      `json:"name,omitempty"`
+	Params   []string    `json:"params"`
+	Defaults []any       `json:"defaults,omitempty"` // the default expressions of the last optional params
+	Rest     bool        `json:"rest,omitempty"`     // the last param is a rest param
+	Body     any         `json:"body"`               // a name is a string, a lambda is an array
+	Frame    []entryJSON `json:"frame"`
+}
+
+type entryJSON struct {
+	Name  string    `json:"name"`
+	Value valueJSON `json:"value"`
+}
+
+// valueJSON - exactly one field is set, none for a nil object
+type valueJSON struct {
+	Builtin *string         `json:"builtin,omitempty"` // the name of the builtin in the base frame
+	Int     *int            `json:"int,omitempty"`
+	Float   *float64        `json:"float,omitempty"`
+	String  *string         `json:"string,omitempty"`
+	Nil     bool            `json:"nil,omitempty"`
+	Unwrap  bool            `json:"unwrap,omitempty"`
+	List    *[]valueJSON    `json:"list,omitempty"`
+	Dict    *[][2]valueJSON `json:"dict,omitempty"`
+	Set     *[]valueJSON    `json:"set,omitempty"`
+	Closure *int            `json:"closure,omitempty"` // the index of the closure
+	Type    *sortJSON       `json:"type,omitempty"`    // a type object
+	Cast    *sortJSON       `json:"cast,omitempty"`    // the type of a value that was cast, with any of the fields above
+}
+
+// sortJSON - an atom with its level and parent or an arrow of sorts
+type sortJSON struct {
+	Atom   *string    `json:"atom,omitempty"`
+	Level  int        `json:"level,omitempty"`
+	Parent *sortJSON  `json:"parent,omitempty"`
+	Arrow  []sortJSON `json:"arrow,omitempty"`
+}
+
+// Snapshot - encode the names of frame that are not the same in base as versioned JSON,
+// closures are stored with their captured frames and bodies, builtins by their name in base
+// chans, tasks and lazy seqs cannot be stored
+func Snapshot(frame Frame, base Frame) ([]byte, error) {
+	e := &snapshotEncoder{
+		base:     base,
+		builtins: map[string]Name{},
+		index:    map[any]int{},
+	}
+	for name, o := range base.Iter {
+		if funcData, ok := o.Data().(runtime.FuncData); ok {
+			if _, ok := e.builtins[funcData.Repr]; !ok {
+				e.builtins[funcData.Repr] = name
+			}
+		}
+	}
+	entries, err := e.encodeFrame(frame)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(snapshotJSON{
+		Version:  SnapshotVersion,
+		Closures: e.closures,
+		Frame:    entries,
+	})
+}
+
+// Restore - decode a snapshot on top of base, builtins are linked to the values of their names in base,
+// e.g. the frame of NewBasicRuntime extended by Register
+func Restore(data []byte, base Frame) (Frame, error) {
+	var s snapshotJSON
+	if err := json.Unmarshal(data, &s); err != nil {
+		return base, err
+	}
+	if s.Version != SnapshotVersion {
+		return base, fmt.Errorf("snapshot version %d is not supported", s.Version)
+	}
+	d := &snapshotDecoder{base: base}
+	for i, c := range s.Closures {
+		o, err := d.decodeClosure(c)
+		if err != nil {
+			return base, fmt.Errorf("closure %d: %w", i, err)
+		}
+		d.closures = append(d.closures, o)
+	}
+	return d.decodeFrame(s.Frame)
+}
+
+type snapshotEncoder struct {
+	base     Frame
+	builtins map[string]Name // from the manual of a builtin to its name in base
+	closures []closureJSON
+	index    map[any]int // from the ID of a closure to its index
+}
+
+func (e *snapshotEncoder) encodeFrame(frame Frame) ([]entryJSON, error) {
+	entries := []entryJSON{}
+	for name, o := range frame.Iter {
+		if e.sameAsBase(name, o) {
+			continue
+		}
+		v, err := e.encodeValue(o)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		entries = append(entries, entryJSON{Name: string(name), Value: v})
+	}
+	return entries, nil
+}
+
+// sameAsBase - whether restoring on top of base gives the object back without storing it
+func (e *snapshotEncoder) sameAsBase(name Name, o Object) bool {
+	b, ok := e.base.Get(name)
+	if !ok || b == nil || o == nil || b.Type().String() != o.Type().String() {
+		return false
+	}
+	switch bd := b.Data().(type) {
+	case nil:
+		return o.Data() == nil && b.String() == o.String()
+	case runtime.FuncData:
+		od, ok := o.Data().(runtime.FuncData)
+		if !ok {
+			return false
+		}
+		_, bIsClosure := bd.Closure()
+		_, oIsClosure := od.Closure()
+		return !bIsClosure && !oIsClosure && bd.Repr == od.Repr
+	default:
+		return checkOrdered(b) == nil && checkOrdered(o) == nil && compareObject(b, o) == 0
+	}
+}
+
+func (e *snapshotEncoder) encodeValue(o Object) (valueJSON, error) {
+	var v valueJSON
+	if o == nil {
+		return v, nil
+	}
+	var restored Object // the object restored without a cast
+	switch data := o.Data().(type) {
+	case nil:
+		s, err := encodeSort(o.Sort())
+		if err != nil {
+			return v, err
+		}
+		v.Type = &s
+		return v, nil
+	case runtime.FuncData:
+		if c, ok := data.Closure(); ok {
+			i, err := e.encodeClosure(c)
+			if err != nil {
+				return v, err
+			}
+			v.Closure = &i
+			restored = runtime.MakeClosure(c)
+			break
+		}
+		name, ok := e.builtins[data.Repr]
+		if !ok {
+			return v, fmt.Errorf("cannot snapshot builtin %s: it is not in the base frame", data.Repr)
+		}
+		builtin := string(name)
+		v.Builtin = &builtin
+		restored, _ = e.base.Get(name)
+	case Int:
+		v.Int = &data.Val
+	case Float:
+		v.Float = &data.Val
+	case String:
+		v.String = &data.Val
+	case runtime.Nil:
+		v.Nil = true
+		restored = makeNil()
+	case Unwrap:
+		v.Unwrap = true
+	case List:
+		l := []valueJSON{}
+		for _, x := range data.Iter {
+			xv, err := e.encodeValue(x)
+			if err != nil {
+				return v, err
+			}
+			l = append(l, xv)
+		}
+		v.List = &l
+	case Dict:
+		d := [][2]valueJSON{}
+		for key, val := range data.Iter {
+			kv, err := e.encodeValue(key)
+			if err != nil {
+				return v, err
+			}
+			vv, err := e.encodeValue(val)
+			if err != nil {
+				return v, err
+			}
+			d = append(d, [2]valueJSON{kv, vv})
+		}
+		v.Dict = &d
+	case Set:
+		l := []valueJSON{}
+		for x := range data.Iter {
+			xv, err := e.encodeValue(x)
+			if err != nil {
+				return v, err
+			}
+			l = append(l, xv)
+		}
+		v.Set = &l
+	default:
+		return v, fmt.Errorf("cannot snapshot %s of type %s", o, typeNameOf(o))
+	}
+	if restored == nil {
+		restored = makeTypedData(o.Data().(TypedData))
+	}
+	if o.Type().String() != restored.Type().String() {
+		s, err := encodeSort(o.Type().Sort())
+		if err != nil {
+			return v, err
+		}
+		v.Cast = &s
+	}
+	return v, nil
+}
+
+// encodeClosure - the index of the closure, its captured frame is encoded first
+func (e *snapshotEncoder) encodeClosure(c runtime.Closure) (int, error) {
+	if i, ok := e.index[c.ID]; ok {
+		return i, nil
+	}
+	frame, err := e.encodeFrame(c.Frame)
+	if err != nil {
+		return 0, err
+	}
+	params := make([]string, 0, len(c.ParamList))
+	for _, param := range c.ParamList {
+		params = append(params, string(param))
+	}
+	var defaults []any
+	for _, d := range c.Defaults {
+		defaults = append(defaults, encodeExpr(d))
+	}
+	e.closures = append(e.closures, closureJSON{
+		Name:     string(c.Name),
+		Params:   params,
+		Defaults: defaults,
+		Rest:     c.Rest,
+		Body:     encodeExpr(c.Body),
+		Frame:    frame,
+	})
+	i := len(e.closures) - 1
+	e.index[c.ID] = i
+	return i, nil
+}
+
+func encodeExpr(e ast.Expr) any {
+	switch e := e.(type) {
+	case ast.Lambda:
+		l := make([]any, 0, len(e))
+		for _, x := range e {
+			l = append(l, encodeExpr(x))
+		}
+		return l
+	default:
+		return e.String()
+	}
+}
+
+// encodeSort - atoms are encoded through the sorts API, arrows do not export their parts,
+// so they are read by reflection, field by field, and checked against the sort when read back
+func encodeSort(s Sort) (sortJSON, error) {
+	if s == nil {
+		return sortJSON{}, fmt.Errorf("cannot snapshot a nil sort")
+	}
+	if s.Length() == 1 {
+		name := s.String()
+		j := sortJSON{Atom: &name, Level: s.Level()}
+		if parent := s.Parent(); !isDefaultParent(s, parent) {
+			p, err := encodeSort(parent)
+			if err != nil {
+				return j, err
+			}
+			j.Parent = &p
+		}
+		return j, nil
+	}
+	j, err := encodeSortValue(reflect.ValueOf(s))
+	if err != nil {
+		return j, fmt.Errorf("cannot snapshot sort %s: %w", s, err)
+	}
+	if d, err := decodeSort(j); err != nil || d.String() != s.String() || d.Level() != s.Level() {
+		return j, fmt.Errorf("cannot snapshot sort %s: its parts do not read back as the sort", s)
+	}
+	return j, nil
+}
+
+// isDefaultParent - the parent an atom without a parent gets from the sorts package,
+// it is not encoded, the chain of default parents is infinite
+func isDefaultParent(s Sort, parent Sort) bool {
+	isDefault := func(p Sort, level int) bool {
+		return p != nil && p.Length() == 1 && p.String() == sorts.DefaultSortName && p.Level() == level
+	}
+	return isDefault(parent, s.Level()+1) && isDefault(parent.Parent(), s.Level()+2)
+}
+
+// encodeSortValue - the parts of a sort read by reflection, an error if they are not laid out as expected
+func encodeSortValue(v reflect.Value) (sortJSON, error) {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return sortJSON{}, fmt.Errorf("nil sort")
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return sortJSON{}, fmt.Errorf("unexpected sort of kind %s", v.Kind())
+	}
+	if params := v.FieldByName("params"); params.IsValid() {
+		// arrow - params is a non-empty slice, the body is the last sort
+		if params.Kind() == reflect.Interface && !params.IsNil() {
+			params = params.Elem()
+		}
+		if params.Kind() != reflect.Slice || params.Len() == 0 {
+			return sortJSON{}, fmt.Errorf("unexpected arrow params of kind %s", params.Kind())
+		}
+		body := v.FieldByName("body")
+		if !body.IsValid() {
+			return sortJSON{}, fmt.Errorf("arrow without a body")
+		}
+		arrow := make([]sortJSON, 0, params.Len()+1)
+		for i := range params.Len() {
+			p, err := encodeSortValue(params.Index(i))
+			if err != nil {
+				return sortJSON{}, err
+			}
+			arrow = append(arrow, p)
+		}
+		b, err := encodeSortValue(body)
+		if err != nil {
+			return sortJSON{}, err
+		}
+		return sortJSON{Arrow: append(arrow, b)}, nil
+	}
+	name, level, parent := v.FieldByName("name"), v.FieldByName("level"), v.FieldByName("parent")
+	if name.Kind() != reflect.String || level.Kind() != reflect.Int || parent.Kind() != reflect.Interface {
+		return sortJSON{}, fmt.Errorf("unexpected atom fields")
+	}
+	n := name.String()
+	s := sortJSON{Atom: &n, Level: int(level.Int())}
+	if !parent.IsNil() {
+		p, err := encodeSortValue(parent)
+		if err != nil {
+			return sortJSON{}, err
+		}
+		s.Parent = &p
+	}
+	return s, nil
+}
+
+type snapshotDecoder struct {
+	base     Frame
+	closures []Object
+}
+
+func (d *snapshotDecoder) decodeFrame(entries []entryJSON) (Frame, error) {
+	frame := d.base
+	for _, entry := range entries {
+		o, err := d.decodeValue(entry.Value)
+		if err != nil {
+			return d.base, fmt.Errorf("%s: %w", entry.Name, err)
+		}
+		frame = frame.Set(Name(entry.Name), o)
+	}
+	return frame, nil
+}
+
+func (d *snapshotDecoder) decodeClosure(c closureJSON) (Object, error) {
+	frame, err := d.decodeFrame(c.Frame)
+	if err != nil {
+		return nil, err
+	}
+	body, err := decodeExpr(c.Body)
+	if err != nil {
+		return nil, err
+	}
+	paramList := make([]Name, 0, len(c.Params))
+	for _, param := range c.Params {
+		paramList = append(paramList, Name(param))
+	}
+	var defaults []ast.Expr
+	for _, x := range c.Defaults {
+		d, err := decodeExpr(x)
+		if err != nil {
+			return nil, err
+		}
+		defaults = append(defaults, d)
+	}
+	rest := 0
+	if c.Rest {
+		rest = 1
+	}
+	if len(defaults)+rest > len(paramList) {
+		return nil, fmt.Errorf("closure %s has more defaults than params", c.Name)
+	}
+	return runtime.MakeClosure(runtime.Closure{
+		Name:      Name(c.Name),
+		ParamList: paramList,
+		Defaults:  defaults,
+		Rest:      c.Rest,
+		Body:      body,
+		Frame:     frame,
+	}), nil
+}
+
+func (d *snapshotDecoder) decodeValue(v valueJSON) (Object, error) {
+	var o Object
+	switch {
+	case v.Builtin != nil:
+		b, ok := d.base.Get(Name(*v.Builtin))
+		if !ok {
+			return nil, fmt.Errorf("builtin %s is not in the base frame", *v.Builtin)
+		}
+		o = b
+	case v.Int != nil:
+		o = makeTypedData(Int{Val: *v.Int})
+	case v.Float != nil:
+		o = makeTypedData(Float{Val: *v.Float})
+	case v.String != nil:
+		o = makeTypedData(String{Val: *v.String})
+	case v.Nil:
+		o = makeNil()
+	case v.Unwrap:
+		o = makeTypedData(Unwrap{})
+	case v.List != nil:
+		l := List{}
+		for _, xv := range *v.List {
+			x, err := d.decodeValue(xv)
+			if err != nil {
+				return nil, err
+			}
+			l = List{l.PushBack(x)}
+		}
+		o = makeTypedData(l)
+	case v.Dict != nil:
+		dict := Dict{}
+		for _, kv := range *v.Dict {
+			key, err := d.decodeValue(kv[0])
+			if err != nil {
+				return nil, err
+			}
+			val, err := d.decodeValue(kv[1])
+			if err != nil {
+				return nil, err
+			}
+			dict = dict.Set(key, val)
+		}
+		o = makeTypedData(dict)
+	case v.Set != nil:
+		set := Set{}
+		for _, xv := range *v.Set {
+			x, err := d.decodeValue(xv)
+			if err != nil {
+				return nil, err
+			}
+			set = set.Ins(x)
+		}
+		o = makeTypedData(set)
+	case v.Closure != nil:
+		if *v.Closure < 0 || *v.Closure >= len(d.closures) {
+			return nil, fmt.Errorf("closure %d is not defined before it is used", *v.Closure)
+		}
+		o = d.closures[*v.Closure]
+	case v.Type != nil:
+		s, err := decodeSort(*v.Type)
+		if err != nil {
+			return nil, err
+		}
+		return runtime.MakeSort(s), nil
+	default:
+		return nil, nil
+	}
+	if v.Cast != nil {
+		s, err := decodeSort(*v.Cast)
+		if err != nil {
+			return nil, err
+		}
+		o = runtime.MakeData(o.Data(), runtime.MakeSort(s))
+	}
+	return o, nil
+}
+
+func decodeExpr(x any) (ast.Expr, error) {
+	switch x := x.(type) {
+	case string:
+		return ast.Name(x), nil
+	case []any:
+		l := make(ast.Lambda, 0, len(x))
+		for _, y := range x {
+			e, err := decodeExpr(y)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, e)
+		}
+		return l, nil
+	default:
+		return nil, fmt.Errorf("expression must be a string or an array: %v", x)
+	}
+}
+
+func decodeSort(s sortJSON) (Sort, error) {
+	if s.Atom == nil {
+		arrow := make([]Sort, 0, len(s.Arrow))
+		for _, a := range s.Arrow {
+			sort, err := decodeSort(a)
+			if err != nil {
+				return nil, err
+			}
+			arrow = append(arrow, sort)
+		}
+		var sort Sort
+		if ok := runtime.Arrow(arrow...).Unwrap(&sort); !ok {
+			return nil, fmt.Errorf("invalid arrow sort")
+		}
+		return sort, nil
+	}
+	var parent Sort
+	if s.Parent != nil {
+		var err error
+		if parent, err = decodeSort(*s.Parent); err != nil {
+			return nil, err
+		}
+	}
+	var sort Sort
+	if ok := sorts.Atom(s.Level, *s.Atom, parent).Unwrap(&sort); !ok {
+		return nil, fmt.Errorf("invalid sort %s at level %d", *s.Atom, s.Level)
+	}
+	return sort, nil
+}
@@ -0,0 +1,143 @@
+package runtime_ext
+
+import (
+	"context"
+	"el/runtime"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+var ErrorSendOnClosedChan = errors.New("send on closed chan")
+var ErrorCloseOfClosedChan = errors.New("close of closed chan")
+
+// Task - the result of a function running on its own goroutine, made by spawn
+type Task struct {
+	*taskState
+}
+
+type taskState struct {
+	repr   string
+	done   chan struct{}
+	result adt.Result[Object] // set before done is closed
+}
+
+func (t Task) String() string {
+	return fmt.Sprintf("task{%s}", t.repr)
+}
+
+func (t Task) TypeName() string {
+	return "task"
+}
+
+// spawnTask - run f on a goroutine, it is interrupted together with ctx
+func spawnTask(ctx context.Context, repr string, f func(ctx context.Context) adt.Result[Object]) Task {
+	t := Task{&taskState{repr: repr, done: make(chan struct{})}}
+	go func() {
+		defer close(t.done)
+		t.result = f(ctx)
+	}()
+	return t
+}
+
+// await - wait for the result of the task or for ctx to be interrupted
+func (t Task) await(ctx context.Context) adt.Result[Object] {
+	select {
+	case <-t.done:
+		return t.result
+	case <-ctx.Done():
+		return resultErr(runtime.CheckInterrupt(ctx))
+	}
+}
+
+// Chan - a channel of objects, closing it never makes a sender panic
+// values sent before close can still be received, after that recv returns nil
+type Chan struct {
+	*chanState
+}
+
+type chanState struct {
+	ch     chan Object
+	closed chan struct{}
+	mu     sync.Mutex
+}
+
+func makeChan(size int) Chan {
+	return Chan{&chanState{
+		ch:     make(chan Object, size),
+		closed: make(chan struct{}),
+	}}
+}
+
+func (c Chan) String() string {
+	return fmt.Sprintf("chan{%d/%d}", len(c.ch), cap(c.ch))
+}
+
+// Size - the buffer of the chan
+func (c Chan) Size() int64 {
+	return 96 + 16*int64(cap(c.ch))
+}
+
+func (c Chan) TypeName() string {
+	return "chan"
+}
+
+func (c Chan) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c Chan) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.isClosed() {
+		return ErrorCloseOfClosedChan
+	}
+	close(c.closed)
+	return nil
+}
+
+// send - a Go nil, e.g. the value of (print), is sent as nil so that the receiver gets a value
+func (c Chan) send(ctx context.Context, o Object) error {
+	if o == nil {
+		o = makeNil()
+	}
+	if c.isClosed() {
+		return ErrorSendOnClosedChan
+	}
+	select {
+	case c.ch <- o:
+		return nil
+	case <-c.closed:
+		return ErrorSendOnClosedChan
+	case <-ctx.Done():
+		return runtime.CheckInterrupt(ctx)
+	}
+}
+
+func (c Chan) recv(ctx context.Context) (Object, error) {
+	select {
+	case o := <-c.ch:
+		return o, nil
+	case <-c.closed:
+		return c.drain(), nil
+	case <-ctx.Done():
+		return nil, runtime.CheckInterrupt(ctx)
+	}
+}
+
+// drain - the next value sent before close, nil if there is none
+func (c Chan) drain() Object {
+	select {
+	case o := <-c.ch:
+		return o
+	default:
+		return makeNil()
+	}
+}
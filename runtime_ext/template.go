@@ -0,0 +1,52 @@
+package runtime_ext
+
+import (
+	"context"
+	"el/ast"
+	"el/parser"
+	"el/runtime"
+	"fmt"
+)
+
+// templateBindings - the name value pairs of the common helpers and operator aliases
+const templateBindings = `
+# identity - identity function
+unit (lambda x x) 
+
+# list helpers
+head (lambda l (get l 0))							# get l[0]
+rest (lambda l (drop l 1))							# get l[1:]
+
+# operators
++ add - sub x mul * mul / div % mod		# short hand for common operator
+== eq != ne <= le < lt > gt >= ge
+&& and || or ! not
+
+# curry
+curry2  {f x => {y => (f x y)}}
+
+# type chain
+-> type_chain
+`
+
+// WithTemplate - wrap a program in the common template of helpers and operator aliases
+func WithTemplate(s string) string {
+	return "\n(let\n" + templateBindings + "\n" + s + "\n\n)"
+}
+
+// Template - the module of a program of top level forms, its frame has the helpers and operator aliases of WithTemplate
+func Template(r Runtime, frame Frame) (*runtime.Module, error) {
+	e, _, err := parser.Parse(parser.Tokenize("(" + templateBindings + ")"))
+	if err != nil {
+		return nil, err
+	}
+	pairs := e.(ast.Lambda)
+	m := &runtime.Module{Frame: frame}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		def := ast.Lambda{ast.Name("def"), pairs[i], pairs[i+1]}
+		if err := m.Run(r, context.Background(), def).Unwrap(new(Object)); err != nil {
+			return nil, fmt.Errorf("template %s: %w", pairs[i], err)
+		}
+	}
+	return m, nil
+}
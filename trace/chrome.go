@@ -0,0 +1,101 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+// ChromeTracer - write calls in the Chrome trace-event format,
+// the output loads in chrome://tracing and https://ui.perfetto.dev
+type ChromeTracer struct {
+	mu    sync.Mutex
+	w     io.Writer
+	begin time.Time
+	count int
+	err   error
+}
+
+func NewChromeTracer(w io.Writer) *ChromeTracer {
+	return &ChromeTracer{w: w, begin: time.Now()}
+}
+
+// chromeEvent - a complete event (ph = X)
+type chromeEvent struct {
+	Name string         `json:"name"`
+	Cat  string         `json:"cat"`
+	Ph   string         `json:"ph"`
+	Ts   float64        `json:"ts"`  // microseconds
+	Dur  float64        `json:"dur"` // microseconds
+	Pid  int            `json:"pid"`
+	Tid  int            `json:"tid"`
+	Args map[string]any `json:"args"`
+}
+
+func (t *ChromeTracer) Enter(ctx context.Context, call *Call) context.Context {
+	return ctx
+}
+
+func (t *ChromeTracer) Exit(ctx context.Context, call *Call, o adt.Result[Object]) {
+	e := makeEvent(call, o)
+	name := string(e.Name)
+	if len(name) == 0 {
+		name = e.Site
+	}
+	args := map[string]any{
+		"site":  e.Site,
+		"depth": e.Depth,
+		"args":  e.Args,
+	}
+	if len(e.Error) > 0 {
+		args["error"] = e.Error
+	} else {
+		args["result"] = e.Result
+	}
+	b, err := json.Marshal(chromeEvent{
+		Name: name,
+		Cat:  string(e.Kind),
+		Ph:   "X",
+		Ts:   float64(call.Start.Sub(t.begin).Nanoseconds()) / 1e3,
+		Dur:  float64(call.Duration.Nanoseconds()) / 1e3,
+		Pid:  1,
+		Tid:  1,
+		Args: args,
+	})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return
+	}
+	if err != nil {
+		t.err = err
+		return
+	}
+	sep := ",\n"
+	if t.count == 0 {
+		sep = "[\n"
+	}
+	t.count++
+	_, t.err = fmt.Fprintf(t.w, "%s%s", sep, b)
+}
+
+// Close - terminate the JSON array, the writer is not closed
+func (t *ChromeTracer) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return t.err
+	}
+	if t.count == 0 {
+		_, t.err = io.WriteString(t.w, "[]\n")
+	} else {
+		_, t.err = io.WriteString(t.w, "\n]\n")
+	}
+	return t.err
+}
@@ -0,0 +1,110 @@
+package trace
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// WriteProfile - write the profile in the gzipped protobuf format read by `go tool pprof`
+// the profile is instrumented, not sampled: every sample is a distinct el call stack
+// with its call count and measured self time, so the duration is left out and pprof does not report them as samples
+func (p *Profiler) WriteProfile(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var b protoBuffer
+	strings := map[string]int64{}
+	var stringTable []string
+	str := func(s string) int64 {
+		i, ok := strings[s]
+		if !ok {
+			i = int64(len(stringTable))
+			strings[s] = i
+			stringTable = append(stringTable, s)
+		}
+		return i
+	}
+	str("")
+
+	valueType := func(typ string, unit string) []byte {
+		var vt protoBuffer
+		vt.int64(1, str(typ))
+		vt.int64(2, str(unit))
+		return vt.bytes
+	}
+	b.message(1, valueType("calls", "count"))           // sample_type
+	b.message(1, valueType("self_time", "nanoseconds")) // sample_type
+	for _, s := range p.samples {
+		var sample protoBuffer
+		locationIDs := make([]uint64, 0, len(s.stack))
+		for _, i := range s.stack {
+			locationIDs = append(locationIDs, uint64(i+1))
+		}
+		sample.packed(1, locationIDs)
+		sample.packed(2, []uint64{uint64(s.calls), uint64(s.self.Nanoseconds())})
+		b.message(2, sample.bytes) // sample
+	}
+	for i := range p.stats {
+		var line protoBuffer
+		line.int64(1, int64(i+1)) // function_id
+		var location protoBuffer
+		location.int64(1, int64(i+1)) // id
+		location.message(4, line.bytes)
+		b.message(4, location.bytes) // location
+	}
+	for i, stat := range p.stats {
+		var function protoBuffer
+		function.int64(1, int64(i+1)) // id
+		function.int64(2, str(funcName(stat.FuncKey)))
+		function.int64(3, str(string(stat.Kind)))
+		function.int64(4, str(stat.Site))
+		b.message(5, function.bytes) // function
+	}
+	b.int64(9, p.begin.UnixNano())                                                        // time_nanos
+	b.int64(13, str("instrumented: call counts and measured self time of every el call")) // comment
+	b.int64(14, str("self_time"))                                                         // default_sample_type
+	for _, s := range stringTable {
+		b.message(6, []byte(s)) // string_table
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(b.bytes); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// protoBuffer - the few protobuf wire encodings profile.proto needs
+type protoBuffer struct {
+	bytes []byte
+}
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.bytes = append(b.bytes, byte(x)|0x80)
+		x >>= 7
+	}
+	b.bytes = append(b.bytes, byte(x))
+}
+
+func (b *protoBuffer) int64(field int, x int64) {
+	if x == 0 {
+		return
+	}
+	b.varint(uint64(field)<<3 | 0)
+	b.varint(uint64(x))
+}
+
+func (b *protoBuffer) message(field int, data []byte) {
+	b.varint(uint64(field)<<3 | 2)
+	b.varint(uint64(len(data)))
+	b.bytes = append(b.bytes, data...)
+}
+
+func (b *protoBuffer) packed(field int, xs []uint64) {
+	var inner protoBuffer
+	for _, x := range xs {
+		inner.varint(x)
+	}
+	b.message(field, inner.bytes)
+}
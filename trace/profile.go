@@ -0,0 +1,150 @@
+package trace
+
+import (
+	"cmp"
+	"context"
+	"el/runtime"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+// Profiler - aggregate time and call counts per function,
+// a function is identified by its kind, let name and definition site
+type Profiler struct {
+	mu      sync.Mutex
+	begin   time.Time
+	index   map[FuncKey]int
+	stats   []FuncStat
+	samples map[string]*profileSample // keyed by the stack of function indices
+}
+
+type FuncKey struct {
+	Kind runtime.CallKind
+	Name runtime.Name
+	Site string
+}
+
+// FuncStat - aggregated calls of one function
+// Self excludes time spent in callees, Cum includes it and counts recursive calls once
+type FuncStat struct {
+	FuncKey
+	Calls int64
+	Self  time.Duration
+	Cum   time.Duration
+}
+
+type profileSample struct {
+	stack []int // function indices, leaf first
+	calls int64
+	self  time.Duration
+}
+
+func NewProfiler() *Profiler {
+	return &Profiler{
+		begin:   time.Now(),
+		index:   make(map[FuncKey]int),
+		samples: make(map[string]*profileSample),
+	}
+}
+
+// profileFrame - a call on the el stack, linked to its caller through the context
+type profileFrame struct {
+	parent   *profileFrame
+	index    int
+	children atomic.Int64 // nanoseconds spent in callees
+}
+
+type profileFrameKey struct {
+	p *Profiler
+}
+
+func (p *Profiler) Enter(ctx context.Context, call *Call) context.Context {
+	key := FuncKey{Kind: call.Kind, Name: call.Name, Site: call.Site}
+	p.mu.Lock()
+	i, ok := p.index[key]
+	if !ok {
+		i = len(p.stats)
+		p.index[key] = i
+		p.stats = append(p.stats, FuncStat{FuncKey: key})
+	}
+	p.mu.Unlock()
+
+	parent, _ := ctx.Value(profileFrameKey{p}).(*profileFrame)
+	return context.WithValue(ctx, profileFrameKey{p}, &profileFrame{parent: parent, index: i})
+}
+
+func (p *Profiler) Exit(ctx context.Context, call *Call, o adt.Result[Object]) {
+	frame, ok := ctx.Value(profileFrameKey{p}).(*profileFrame)
+	if !ok {
+		return
+	}
+	self := call.Duration - time.Duration(frame.children.Load())
+	if frame.parent != nil {
+		frame.parent.children.Add(call.Duration.Nanoseconds())
+	}
+
+	recursive := false
+	var stack []int
+	var sb strings.Builder
+	for f := frame; f != nil; f = f.parent {
+		if f != frame && f.index == frame.index {
+			recursive = true
+		}
+		stack = append(stack, f.index)
+		sb.WriteString(strconv.Itoa(f.index))
+		sb.WriteByte(',')
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stat := &p.stats[frame.index]
+	stat.Calls++
+	stat.Self += self
+	if !recursive {
+		stat.Cum += call.Duration
+	}
+	s, ok := p.samples[sb.String()]
+	if !ok {
+		s = &profileSample{stack: stack}
+		p.samples[sb.String()] = s
+	}
+	s.calls++
+	s.self += self
+}
+
+// Stats - per function statistics, sorted by self time
+func (p *Profiler) Stats() []FuncStat {
+	p.mu.Lock()
+	stats := slices.Clone(p.stats)
+	p.mu.Unlock()
+	slices.SortStableFunc(stats, func(a, b FuncStat) int {
+		return cmp.Compare(b.Self, a.Self)
+	})
+	return stats
+}
+
+// WriteReport - write a human readable table of Stats
+func (p *Profiler) WriteReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "self\tcum\tcalls\t\tname\tsite\t\n")
+	for _, stat := range p.Stats() {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t\t%s\t%s\t\n", stat.Self, stat.Cum, stat.Calls, funcName(stat.FuncKey), stat.Site)
+	}
+	return tw.Flush()
+}
+
+func funcName(key FuncKey) string {
+	if len(key.Name) == 0 {
+		return "(anonymous)"
+	}
+	return string(key.Name)
+}
@@ -0,0 +1,110 @@
+// Package trace - tracers and a profiler for el programs, plugged in through runtime.Runtime.Tracer
+package trace
+
+import (
+	"context"
+	"el/runtime"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fbundle/lab_public/lab/go_util/pkg/adt"
+)
+
+type Object = runtime.Object
+type Tracer = runtime.Tracer
+type Call = runtime.Call
+
+// Event - a finished call, one line of the JSON lines trace
+type Event struct {
+	Kind       runtime.CallKind `json:"kind"`
+	Name       runtime.Name     `json:"name,omitempty"`
+	Site       string           `json:"site"`
+	Depth      int              `json:"depth"`
+	Args       []string         `json:"args"`
+	Result     string           `json:"result,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	Start      time.Time        `json:"start"`
+	DurationNs int64            `json:"duration_ns"`
+}
+
+func makeEvent(call *Call, o adt.Result[Object]) Event {
+	args := make([]string, 0, len(call.Args))
+	for _, arg := range call.Args {
+		args = append(args, objectString(arg))
+	}
+	e := Event{
+		Kind:       call.Kind,
+		Name:       call.Name,
+		Site:       call.Site,
+		Depth:      call.Depth,
+		Args:       args,
+		Start:      call.Start,
+		DurationNs: call.Duration.Nanoseconds(),
+	}
+	if o.Err != nil {
+		e.Error = o.Err.Error()
+	} else {
+		e.Result = objectString(o.Val)
+	}
+	return e
+}
+
+func objectString(o Object) string {
+	if o == nil {
+		return "nil"
+	}
+	return o.String()
+}
+
+// JSONTracer - write every call as a JSON line when it returns
+type JSONTracer struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+func NewJSONTracer(w io.Writer) *JSONTracer {
+	return &JSONTracer{enc: json.NewEncoder(w)}
+}
+
+func (t *JSONTracer) Enter(ctx context.Context, call *Call) context.Context {
+	return ctx
+}
+
+func (t *JSONTracer) Exit(ctx context.Context, call *Call, o adt.Result[Object]) {
+	e := makeEvent(call, o)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err == nil {
+		t.err = t.enc.Encode(e)
+	}
+}
+
+// Err - the first write error, if any
+func (t *JSONTracer) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// Multi - fan out every call to several tracers
+func Multi(tracers ...Tracer) Tracer {
+	return multiTracer(tracers)
+}
+
+type multiTracer []Tracer
+
+func (m multiTracer) Enter(ctx context.Context, call *Call) context.Context {
+	for _, t := range m {
+		ctx = t.Enter(ctx, call)
+	}
+	return ctx
+}
+
+func (m multiTracer) Exit(ctx context.Context, call *Call, o adt.Result[Object]) {
+	for i := len(m) - 1; i >= 0; i-- {
+		m[i].Exit(ctx, call, o)
+	}
+}